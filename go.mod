@@ -5,6 +5,11 @@ go 1.24.0
 require (
 	github.com/beevik/etree v1.6.0
 	github.com/ncruces/go-sqlite3 v0.29.1
+	golang.org/x/net v0.44.0
+	golang.org/x/text v0.29.0
+)
+
+require (
 	github.com/ncruces/julianday v1.0.0 // indirect
 	github.com/tetratelabs/wazero v1.9.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
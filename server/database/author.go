@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// AuthorSerialize serializes a person of the authors table. The id is left to the database
+func AuthorSerialize(p *rss.Person) ([]any, string) {
+	values := []any{nil, p.Name, p.Email, urlString(p.URI)}
+	return values, placeholders(len(values))
+}
+
+// AuthorDeserialize returns a person of the authors table with its id
+func AuthorDeserialize(r RowScanner) (*rss.Person, int, error) {
+	var id int
+	var name, email, uri string
+
+	if err := r.Scan(&id, &name, &email, &uri); err != nil {
+		return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.Person{
+		Name:  name,
+		Email: email,
+		URI:   safeURLParse(sql.NullString{String: uri, Valid: uri != ""}),
+	}, id, nil
+}
+
+func ItemAuthorSerialize(itemID, authorID, position int) ([]any, string) {
+	values := []any{itemID, authorID, position}
+	return values, placeholders(len(values))
+}
+
+// insertAuthor inserts p unless the same person is already stored, and returns its id
+func insertAuthor(ctx context.Context, tx *sql.Tx, p *rss.Person) (int, error) {
+	values, ph := AuthorSerialize(p)
+
+	// the no-op update makes RETURNING give the id of the existing row
+	var id int
+	err := tx.QueryRowContext(ctx, insertInto("authors")+ph+
+		" ON CONFLICT(name, email, uri) DO UPDATE SET name = excluded.name RETURNING id", values...).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert into authors: %w", err)
+	}
+	return id, nil
+}
+
+// ItemAuthors returns the authors of the item with the id itemID, in the order of the feed
+func ItemAuthors(ctx context.Context, db *sql.DB, itemID int) ([]*rss.Person, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+columns("authors")+` FROM authors
+		JOIN item_authors ON item_authors.author_id = authors.id
+		WHERE item_authors.item_id = ?
+		ORDER BY item_authors.position`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authors of item %d: %w", itemID, err)
+	}
+	defer rows.Close()
+
+	var authors []*rss.Person
+	for rows.Next() {
+		p, _, err := AuthorDeserialize(rows)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query authors of item %d: %w", itemID, err)
+	}
+
+	return authors, nil
+}
+
+// ItemsByAuthor returns the items of the authors with the name or email address author across all feeds, newest first. Both are compared case-insensitively
+// The items are returned without their enclosures and categories, and with a Feed that only has its DatabaseID set
+func ItemsByAuthor(ctx context.Context, db *sql.DB, author string) ([]*rss.Item, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+columns("items")+` FROM items
+		WHERE items.id IN (SELECT item_authors.item_id FROM item_authors
+			JOIN authors ON authors.id = item_authors.author_id
+			WHERE authors.name = ?1 COLLATE NOCASE OR authors.email = ?1 COLLATE NOCASE)
+		ORDER BY datetime(items.pubDate) DESC`, author)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items of author %q: %w", author, err)
+	}
+	defer rows.Close()
+
+	var items []*rss.Item
+	for rows.Next() {
+		item, err := ItemDeserialize(rows, nil)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query items of author %q: %w", author, err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,93 @@
+package database_test
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/server/database"
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+func TestAuthorSerialize(t *testing.T) {
+	uri, _ := url.Parse("http://example.org/")
+	p := &rss.Person{Name: "Mark Pilgrim", Email: "f8dy@example.com", URI: uri}
+
+	values, ph := database.AuthorSerialize(p)
+	if ph != "(?,?,?,?)" || values[0] != nil || values[3].(string) != "http://example.org/" {
+		t.Fatalf("unexpected values %v %q", values, ph)
+	}
+
+	m := mockRow{values: []any{7, "Sam Ruby", "", ""}}
+	got, id, err := database.AuthorDeserialize(&m)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if id != 7 || got.Name != "Sam Ruby" || got.Email != "" || got.URI != nil {
+		t.Fatalf("unexpected author %d %+v", id, got)
+	}
+}
+
+func TestItemsByAuthor(t *testing.T) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "fedup.db"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	feeds := []*rss.Feed{
+		{DatabaseID: 1, Title: "First", Description: "Test"},
+		{DatabaseID: 2, Title: "Second", Description: "Test"},
+	}
+	for _, f := range feeds {
+		values, ph := database.FeedSerialize(f)
+		if _, err := db.ExecContext(ctx, "INSERT INTO feeds VALUES "+ph, values...); err != nil {
+			t.Fatalf("%s", err)
+		}
+	}
+
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.AddDate(0, 1, 0)
+	items := []*rss.Item{
+		{Feed: feeds[0], GUID: "1", Title: "Joint", PubDate: &older, Authors: []*rss.Person{{Name: "Joe Bloggs", Email: "joe@example.com"}, {Name: "Alice"}}},
+		{Feed: feeds[1], GUID: "2", Title: "Guest post", PubDate: &newer, Authors: []*rss.Person{{Name: "Joe Bloggs", Email: "joe@example.com"}}},
+		{Feed: feeds[1], GUID: "3", Title: "Someone else", Authors: []*rss.Person{{Name: "Bob"}}},
+	}
+	for _, i := range items {
+		if err := database.InsertItem(ctx, db, i); err != nil {
+			t.Fatalf("%s", err)
+		}
+	}
+
+	// the same person is stored once
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM authors").Scan(&n); err != nil || n != 3 {
+		t.Fatalf("expected 3 authors, got %d (%v)", n, err)
+	}
+
+	for _, author := range []string{"joe bloggs", "JOE@example.com"} {
+		got, err := database.ItemsByAuthor(ctx, db, author)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(got) != 2 || got[0].Title != "Guest post" || got[1].Title != "Joint" {
+			t.Fatalf("expected the items of Joe across feeds, newest first, got %+v", got)
+		}
+		if got[0].Feed.DatabaseID != 2 || got[1].Feed.DatabaseID != 1 {
+			t.Fatalf("unexpected feeds %d and %d", got[0].Feed.DatabaseID, got[1].Feed.DatabaseID)
+		}
+	}
+
+	authors, err := database.ItemAuthors(ctx, db, items[0].DatabaseID)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if len(authors) != 2 || authors[0].Email != "joe@example.com" || authors[1].Name != "Alice" {
+		t.Fatalf("unexpected authors %+v", authors)
+	}
+}
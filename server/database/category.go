@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// CategorySerialize serializes a category of a feed or an item. The ID of the other is 0, and stored as NULL
+func CategorySerialize(c *rss.Category, feedID, itemID int) ([]any, string) {
+	values := []any{nullableID(feedID), nullableID(itemID), c.Term, c.Domain}
+	return values, placeholders(len(values))
+}
+
+func CategoryDeserialize(r RowScanner) (*rss.Category, error) {
+	var feedID, itemID sql.NullInt64
+	var term string
+	var domain sql.NullString
+
+	if err := r.Scan(&feedID, &itemID, &term, &domain); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.Category{Term: term, Domain: domain.String}, nil
+}
+
+// ItemsByCategory returns the items in the category term, newest first. Terms are compared case-insensitively and the domain is ignored
+// The items are returned without their enclosures and categories, and with a Feed that only has its DatabaseID set
+func ItemsByCategory(ctx context.Context, db *sql.DB, term string) ([]*rss.Item, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+columns("items")+` FROM items
+		WHERE items.id IN (SELECT item_id FROM categories WHERE term = ? COLLATE NOCASE)
+		ORDER BY datetime(items.pubDate) DESC`, term)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items in category %q: %w", term, err)
+	}
+	defer rows.Close()
+
+	var items []*rss.Item
+	for rows.Next() {
+		item, err := ItemDeserialize(rows, nil)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query items in category %q: %w", term, err)
+	}
+
+	return items, nil
+}
+
+// FeedsByCategory returns the feeds in the category term. Terms are compared case-insensitively and the domain is ignored
+func FeedsByCategory(ctx context.Context, db *sql.DB, term string) ([]*rss.Feed, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+columns("feeds")+` FROM feeds
+		WHERE feeds.id IN (SELECT feed_id FROM categories WHERE term = ? COLLATE NOCASE)
+		ORDER BY feeds.title`, term)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feeds in category %q: %w", term, err)
+	}
+	defer rows.Close()
+
+	var feeds []*rss.Feed
+	for rows.Next() {
+		feed, err := FeedDeserialize(rows)
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, feed)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query feeds in category %q: %w", term, err)
+	}
+
+	return feeds, nil
+}
@@ -0,0 +1,121 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/server/database"
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+func TestCategorySerialize(t *testing.T) {
+	c := &rss.Category{Term: "Space Station", Domain: "https://www.nasa.gov/topics"}
+
+	placeholders, ph := database.CategorySerialize(c, 4, 0)
+	if ph != "(?,?,?,?)" {
+		t.Fatalf("unexpected placeholders %q", ph)
+	}
+
+	if placeholders[0].(int) != 4 || placeholders[1] != nil || placeholders[2].(string) != c.Term {
+		t.Fatalf("unexpected values %v", placeholders)
+	}
+
+	m := mockRow{values: []any{4, nil, c.Term, nil}}
+	got, err := database.CategoryDeserialize(&m)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if got.Term != c.Term || got.Domain != "" {
+		t.Fatalf("unexpected category %+v", got)
+	}
+}
+
+func TestItemsByCategory(t *testing.T) {
+	// a file, because every connection to :memory: gets its own database
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "fedup.db"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	f := &rss.Feed{DatabaseID: 1, Title: expectedFeedTitle, Description: "Test"}
+	values, ph := database.FeedSerialize(f)
+	if _, err := db.ExecContext(ctx, "INSERT INTO feeds VALUES "+ph, values...); err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	// the earlier item sorts after the other as a string, because of its offset
+	later := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	earlier := time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("IST", 5*3600+1800))
+	items := []*rss.Item{
+		{DatabaseID: 1, Feed: f, GUID: "1", Title: "Tagged", PubDate: &later, Categories: []*rss.Category{{Term: "Science"}, {Term: "Space"}}},
+		{DatabaseID: 2, Feed: f, GUID: "2", Title: "Untagged", Categories: []*rss.Category{{Term: "Sports"}}},
+		{DatabaseID: 3, Feed: f, GUID: "3", Title: "Earlier", PubDate: &earlier, Categories: []*rss.Category{{Term: "science"}}},
+	}
+	for _, i := range items {
+		values, ph := database.ItemSerialize(i)
+		if _, err := db.ExecContext(ctx, "INSERT INTO items VALUES "+ph, values...); err != nil {
+			t.Fatalf("%s", err)
+		}
+		for _, c := range i.Categories {
+			values, ph := database.CategorySerialize(c, 0, i.DatabaseID)
+			if _, err := db.ExecContext(ctx, "INSERT INTO categories VALUES "+ph, values...); err != nil {
+				t.Fatalf("%s", err)
+			}
+		}
+	}
+
+	got, err := database.ItemsByCategory(ctx, db, "science")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if len(got) != 2 || got[0].Title != "Tagged" || got[1].Title != "Earlier" {
+		t.Fatalf("expected the tagged items, newest first, got %+v", got)
+	}
+
+	if got[0].Feed.DatabaseID != f.DatabaseID {
+		t.Fatalf("expected feed id %d, got %d", f.DatabaseID, got[0].Feed.DatabaseID)
+	}
+}
+
+func TestFeedsByCategory(t *testing.T) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "fedup.db"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for _, in := range []string{
+		`<rss version="2.0"><channel><title>Tagged</title><link>https://example.com/</link><description>Test</description><category>Science</category><category>Space</category></channel></rss>`,
+		`<rss version="2.0"><channel><title>Untagged</title><link>https://example.org/</link><description>Test</description><category>Sports</category></channel></rss>`,
+		`<feed xmlns="http://www.w3.org/2005/Atom"><title>Atom</title><id>urn:example</id><updated>2024-01-01T10:00:00Z</updated><category term="science"/></feed>`,
+	} {
+		feed, err := rss.Parse(strings.NewReader(in), "")
+		if err != nil {
+			t.Fatalf("Parse: %s", err)
+		}
+		if err := database.StoreFeed(ctx, db, feed); err != nil {
+			t.Fatalf("StoreFeed: %s", err)
+		}
+	}
+
+	got, err := database.FeedsByCategory(ctx, db, "science")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if len(got) != 2 || got[0].Title != "Atom" || got[1].Title != "Tagged" {
+		t.Fatalf("expected the tagged feeds by title, got %+v", got)
+	}
+}
@@ -32,6 +32,9 @@ func InitDB(name string) (*sql.DB, error) {
 	if _, err := db.ExecContext(ctx2, init_query); err != nil {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
+	if err := migrate(ctx2, db); err != nil {
+		return nil, err
+	}
 
 	return db, nil
 }
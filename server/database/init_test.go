@@ -1,10 +1,14 @@
 package database_test
 
 import (
+	"context"
 	"database/sql"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/its-mrarsikk/fedup/server/database"
+	"github.com/its-mrarsikk/fedup/shared/rss"
 )
 
 func TestInit(t *testing.T) {
@@ -22,3 +26,100 @@ func TestInit(t *testing.T) {
 		t.Fatalf("%s", err)
 	}
 }
+
+// the tables of a database created before the schema was versioned
+const schemaV0 = `
+CREATE TABLE feeds (
+    id INTEGER PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+	link TEXT,
+	fetchFrom TEXT,
+    language TEXT,
+    ttl INTEGER
+);
+CREATE TABLE items (
+    id INTEGER PRIMARY KEY,
+    feed_id INTEGER NOT NULL,
+    guid TEXT UNIQUE,
+    title TEXT,
+    description TEXT,
+    link TEXT,
+    author TEXT,
+    pubDate TEXT,
+    read BOOLEAN NOT NULL DEFAULT 0,
+    enclosure_url TEXT,
+    enclosure_type TEXT,
+    enclosure_length INTEGER,
+    FOREIGN KEY(feed_id) REFERENCES feeds(id)
+);
+INSERT INTO feeds VALUES (1, 'Old', 'Test', 'https://example.com/', NULL, 'en', 60);
+INSERT INTO items VALUES (1, 1, 'old-1', 'Episode', 'Test', NULL, NULL, '2024-01-01T00:00:00Z', 1, 'https://example.com/1.mp3', 'audio/mpeg', 1234);
+INSERT INTO items VALUES (2, 1, 'old-2', 'Post', 'Test', NULL, NULL, '2024-01-02T00:00:00Z', 0, NULL, NULL, NULL);
+`
+
+func TestMigrate(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "fedup.db")
+	old, err := sql.Open("sqlite3", name)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if _, err := old.Exec(schemaV0); err != nil {
+		t.Fatalf("%s", err)
+	}
+	old.Close()
+
+	db, err := database.InitDB(name)
+	if err != nil {
+		t.Fatalf("InitDB: %s", err)
+	}
+	defer func() { db.Close() }()
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil || version != 1 {
+		t.Fatalf("expected schema version 1, got %d (%v)", version, err)
+	}
+
+	// the enclosure of the old item is in its own table
+	var url, mimeType string
+	var length int
+	if err := db.QueryRow("SELECT url, type, length FROM enclosures WHERE item_id = 1").Scan(&url, &mimeType, &length); err != nil {
+		t.Fatalf("expected the enclosure to be moved: %s", err)
+	}
+	if url != "https://example.com/1.mp3" || mimeType != "audio/mpeg" || length != 1234 {
+		t.Fatalf("unexpected enclosure %q %q %d", url, mimeType, length)
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM enclosures").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("expected one enclosure, got %d (%v)", n, err)
+	}
+
+	// new items and categories fit the migrated tables, and old items are read back
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	pubDate := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	item := &rss.Item{Feed: &rss.Feed{DatabaseID: 1}, GUID: "new", Title: "New", PubDate: &pubDate, Content: "<p>Hi</p>", Categories: []*rss.Category{{Term: "News"}}}
+	if err := database.InsertItem(ctx, db, item); err != nil {
+		t.Fatalf("InsertItem: %s", err)
+	}
+	if _, err := db.Exec("INSERT INTO categories (item_id, term) VALUES (1, 'News')"); err != nil {
+		t.Fatalf("%s", err)
+	}
+	items, err := database.ItemsByCategory(ctx, db, "news")
+	if err != nil {
+		t.Fatalf("ItemsByCategory: %s", err)
+	}
+	if len(items) != 2 || items[1].GUID != "old-1" || !items[1].Read || items[0].Content != "<p>Hi</p>" {
+		t.Fatalf("unexpected items %+v", items)
+	}
+
+	// opening it again does not migrate twice
+	db.Close()
+	db, err = database.InitDB(name)
+	if err != nil {
+		t.Fatalf("InitDB: %s", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM enclosures").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("expected one enclosure, got %d (%v)", n, err)
+	}
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// MediaSerialize serializes a media object without its contents and thumbnails, which are stored in their own tables
+// position is the index of the object in rss.Item.Media, and identifies it together with the item
+func MediaSerialize(m *rss.Media, itemID, position int) ([]any, string) {
+	values := []any{itemID, position, m.Title, m.Description}
+	return values, placeholders(len(values))
+}
+
+// MediaDeserialize returns a media object without its contents and thumbnails, and its position in the item
+func MediaDeserialize(r RowScanner) (*rss.Media, int, error) {
+	var itemID, position int
+	var title, description sql.NullString
+
+	if err := r.Scan(&itemID, &position, &title, &description); err != nil {
+		return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.Media{Title: title.String, Description: description.String}, position, nil
+}
+
+func MediaContentSerialize(c *rss.MediaContent, itemID, position int) ([]any, string) {
+	values := []any{
+		itemID,
+		position,
+		urlString(c.URL),
+		c.MimeType,
+		c.Medium,
+		c.FileSize,
+		int64(c.Duration / time.Second),
+		c.Width,
+		c.Height,
+		c.Bitrate,
+		c.Lang,
+		c.IsDefault,
+	}
+	return values, placeholders(len(values))
+}
+
+// MediaContentDeserialize returns a rendition and the position of the media object it belongs to
+func MediaContentDeserialize(r RowScanner) (*rss.MediaContent, int, error) {
+	var itemID, position int
+	var rawURL string
+	var mimeType, medium, lang sql.NullString
+	var fileSize, duration, width, height, bitrate sql.NullInt64
+	var isDefault bool
+
+	if err := r.Scan(&itemID, &position, &rawURL, &mimeType, &medium, &fileSize, &duration, &width, &height, &bitrate, &lang, &isDefault); err != nil {
+		return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.MediaContent{
+		URL:       safeURLParse(sql.NullString{String: rawURL, Valid: true}),
+		MimeType:  mimeType.String,
+		Medium:    medium.String,
+		FileSize:  fileSize.Int64,
+		Duration:  time.Duration(duration.Int64) * time.Second,
+		Width:     int(width.Int64),
+		Height:    int(height.Int64),
+		Bitrate:   int(bitrate.Int64),
+		Lang:      lang.String,
+		IsDefault: isDefault,
+	}, position, nil
+}
+
+func MediaThumbnailSerialize(t *rss.MediaThumbnail, itemID, position int) ([]any, string) {
+	values := []any{itemID, position, urlString(t.URL), t.Width, t.Height}
+	return values, placeholders(len(values))
+}
+
+// MediaThumbnailDeserialize returns a thumbnail and the position of the media object it belongs to
+func MediaThumbnailDeserialize(r RowScanner) (*rss.MediaThumbnail, int, error) {
+	var itemID, position int
+	var rawURL string
+	var width, height sql.NullInt64
+
+	if err := r.Scan(&itemID, &position, &rawURL, &width, &height); err != nil {
+		return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.MediaThumbnail{
+		URL:    safeURLParse(sql.NullString{String: rawURL, Valid: true}),
+		Width:  int(width.Int64),
+		Height: int(height.Int64),
+	}, position, nil
+}
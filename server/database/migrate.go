@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// The version of the schema in sql/init.sql, stored in PRAGMA user_version. Databases created before it was versioned have version 0
+const schemaVersion = 1
+
+// The columns that were added to the tables of version 0, with their definitions in sql/init.sql
+var addedColumns = map[string][]string{
+	"feeds": {
+		"itunes_author TEXT", "itunes_summary TEXT", "itunes_image TEXT", "itunes_explicit BOOLEAN", "itunes_type TEXT",
+		"podcast_guid TEXT", "podcast_locked BOOLEAN", "podcast_locked_owner TEXT",
+		"copyright TEXT", "publisher TEXT", "managing_editor TEXT", "web_master TEXT", "pub_date TEXT", "last_build_date TEXT",
+		"generator TEXT", "docs TEXT", "rating TEXT",
+		"image_url TEXT", "image_title TEXT", "image_link TEXT", "image_width INTEGER", "image_height INTEGER", "image_description TEXT",
+		"text_input_title TEXT", "text_input_description TEXT", "text_input_name TEXT", "text_input_link TEXT",
+	},
+	"items": {
+		"content TEXT",
+		"itunes_author TEXT", "itunes_summary TEXT", "itunes_image TEXT", "itunes_duration INTEGER", "itunes_episode INTEGER",
+		"itunes_season INTEGER", "itunes_explicit BOOLEAN", "itunes_episode_type TEXT",
+		"description_text TEXT", "guid_is_permalink BOOLEAN NOT NULL DEFAULT 0",
+	},
+}
+
+// tableHasColumn reports whether table has a column named column
+func tableHasColumn(ctx context.Context, tx *sql.Tx, table, column string) (bool, error) {
+	var n int
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	return n > 0, nil
+}
+
+// migrateV1 upgrades the feeds and items tables of version 0 to version 1: columns are added for the new metadata,
+// and the single enclosure of an item moves from its enclosure_* columns to the enclosures table. The tables of a new database are left as they are
+func migrateV1(ctx context.Context, tx *sql.Tx) error {
+	for _, table := range []string{"feeds", "items"} {
+		for _, def := range addedColumns[table] {
+			name := strings.Fields(def)[0]
+			has, err := tableHasColumn(ctx, tx, table, name)
+			if err != nil {
+				return err
+			}
+			if has {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN "+def); err != nil {
+				return fmt.Errorf("failed to add column %s to %s: %w", name, table, err)
+			}
+		}
+	}
+
+	has, err := tableHasColumn(ctx, tx, "items", "enclosure_url")
+	if err != nil || !has {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO enclosures (item_id, url, type, length)
+		SELECT id, enclosure_url, enclosure_type, enclosure_length FROM items WHERE enclosure_url IS NOT NULL AND enclosure_url != ''`); err != nil {
+		return fmt.Errorf("failed to move enclosures: %w", err)
+	}
+	for _, column := range []string{"enclosure_url", "enclosure_type", "enclosure_length"} {
+		if _, err := tx.ExecContext(ctx, "ALTER TABLE items DROP COLUMN "+column); err != nil {
+			return fmt.Errorf("failed to drop column %s from items: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// migrations[i] upgrades a database from version i to version i+1
+var migrations = []func(ctx context.Context, tx *sql.Tx) error{
+	migrateV1,
+}
+
+// migrate upgrades the tables of a database created by an older version of fedupd to schemaVersion, in one transaction.
+// It runs after sql/init.sql, which creates the tables that did not exist yet
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version >= schemaVersion {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for v := version; v < schemaVersion; v++ {
+		if err := migrations[v](ctx, tx); err != nil {
+			return fmt.Errorf("failed to migrate schema to version %d: %w", v+1, err)
+		}
+	}
+	// PRAGMA does not take parameters
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
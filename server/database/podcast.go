@@ -0,0 +1,141 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// nullableID returns id, or nil (NULL) if id is 0
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func TranscriptSerialize(t *rss.PodcastTranscript, itemID int) ([]any, string) {
+	values := []any{itemID, urlString(t.URL), t.MimeType, t.Language, t.Rel}
+	return values, placeholders(len(values))
+}
+
+func TranscriptDeserialize(r RowScanner) (*rss.PodcastTranscript, error) {
+	var itemID int
+	var rawURL string
+	var mimeType, language, rel sql.NullString
+
+	if err := r.Scan(&itemID, &rawURL, &mimeType, &language, &rel); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.PodcastTranscript{
+		URL:      safeURLParse(sql.NullString{String: rawURL, Valid: true}),
+		MimeType: mimeType.String,
+		Language: language.String,
+		Rel:      rel.String,
+	}, nil
+}
+
+func ChaptersSerialize(c *rss.PodcastChapters, itemID int) ([]any, string) {
+	values := []any{itemID, urlString(c.URL), c.MimeType}
+	return values, placeholders(len(values))
+}
+
+func ChaptersDeserialize(r RowScanner) (*rss.PodcastChapters, error) {
+	var itemID int
+	var rawURL string
+	var mimeType sql.NullString
+
+	if err := r.Scan(&itemID, &rawURL, &mimeType); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.PodcastChapters{
+		URL:      safeURLParse(sql.NullString{String: rawURL, Valid: true}),
+		MimeType: mimeType.String,
+	}, nil
+}
+
+// PersonSerialize serializes a person of a feed or an item. The ID of the other is 0, and stored as NULL
+func PersonSerialize(p *rss.PodcastPerson, feedID, itemID int) ([]any, string) {
+	values := []any{nullableID(feedID), nullableID(itemID), p.Name, p.Role, p.Group, urlString(p.Image), urlString(p.Href)}
+	return values, placeholders(len(values))
+}
+
+func PersonDeserialize(r RowScanner) (*rss.PodcastPerson, error) {
+	var feedID, itemID sql.NullInt64
+	var name string
+	var role, group, img, href sql.NullString
+
+	if err := r.Scan(&feedID, &itemID, &name, &role, &group, &img, &href); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.PodcastPerson{
+		Name:  name,
+		Role:  role.String,
+		Group: group.String,
+		Image: safeURLParse(img),
+		Href:  safeURLParse(href),
+	}, nil
+}
+
+func FundingSerialize(f *rss.PodcastFunding, feedID int) ([]any, string) {
+	values := []any{feedID, urlString(f.URL), f.Text}
+	return values, placeholders(len(values))
+}
+
+func FundingDeserialize(r RowScanner) (*rss.PodcastFunding, error) {
+	var feedID int
+	var rawURL string
+	var text sql.NullString
+
+	if err := r.Scan(&feedID, &rawURL, &text); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.PodcastFunding{
+		URL:  safeURLParse(sql.NullString{String: rawURL, Valid: true}),
+		Text: text.String,
+	}, nil
+}
+
+// ATTACHMENTS //
+
+func AttachmentSerialize(a *rss.Attachment) ([]any, string) {
+	var lastModified string
+	if !a.LastModified.IsZero() {
+		lastModified = a.LastModified.Format(http.TimeFormat)
+	}
+
+	values := []any{urlString(a.URL), a.ContentType, a.ETag, lastModified, a.Data}
+	return values, placeholders(len(values))
+}
+
+func AttachmentDeserialize(r RowScanner) (*rss.Attachment, error) {
+	var rawURL string
+	var contentType, etag, lastModified sql.NullString
+	var data []byte
+
+	if err := r.Scan(&rawURL, &contentType, &etag, &lastModified, &data); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	a := &rss.Attachment{
+		URL:         safeURLParse(sql.NullString{String: rawURL, Valid: true}),
+		ContentType: contentType.String,
+		ETag:        etag.String,
+		Data:        data,
+	}
+
+	if lastModified.Valid {
+		if t, err := time.Parse(http.TimeFormat, lastModified.String); err == nil {
+			a.LastModified = t
+		}
+	}
+
+	return a, nil
+}
@@ -0,0 +1,43 @@
+package database
+
+import "strings"
+
+// The columns of the tables in sql/init.sql, in the order the Serialize functions return their values and the Deserialize functions scan them.
+// Queries name the columns, because the tables of a migrated database may have them in a different order than a new one
+var tableColumns = map[string][]string{
+	"feeds": {"id", "title", "description", "link", "fetchFrom", "language", "ttl",
+		"itunes_author", "itunes_summary", "itunes_image", "itunes_explicit", "itunes_type",
+		"podcast_guid", "podcast_locked", "podcast_locked_owner",
+		"copyright", "publisher", "managing_editor", "web_master", "pub_date", "last_build_date", "generator", "docs", "rating",
+		"image_url", "image_title", "image_link", "image_width", "image_height", "image_description",
+		"text_input_title", "text_input_description", "text_input_name", "text_input_link"},
+	"items": {"id", "feed_id", "guid", "title", "description", "link", "author", "pubDate", "read", "content",
+		"itunes_author", "itunes_summary", "itunes_image", "itunes_duration", "itunes_episode", "itunes_season", "itunes_explicit", "itunes_episode_type",
+		"description_text", "guid_is_permalink"},
+	"enclosures":          {"item_id", "url", "type", "length"},
+	"categories":          {"feed_id", "item_id", "term", "domain"},
+	"podcast_transcripts": {"item_id", "url", "type", "language", "rel"},
+	"podcast_chapters":    {"item_id", "url", "type"},
+	"podcast_persons":     {"feed_id", "item_id", "name", "role", "person_group", "img", "href"},
+	"podcast_funding":     {"feed_id", "url", "text"},
+	"attachments":         {"url", "content_type", "etag", "last_modified", "data"},
+	"media":               {"item_id", "position", "title", "description"},
+	"media_contents":      {"item_id", "media_position", "url", "type", "medium", "file_size", "duration", "width", "height", "bitrate", "lang", "is_default"},
+	"media_thumbnails":    {"item_id", "media_position", "url", "width", "height"},
+	"authors":             {"id", "name", "email", "uri"},
+	"item_authors":        {"item_id", "author_id", "position"},
+}
+
+// columns returns the columns of table qualified with its name, like "items.id, items.feed_id", to select a whole row in the order Deserialize scans it
+func columns(table string) string {
+	cols := make([]string, len(tableColumns[table]))
+	for i, c := range tableColumns[table] {
+		cols[i] = table + "." + c
+	}
+	return strings.Join(cols, ", ")
+}
+
+// insertInto returns the start of a statement that inserts a whole row into table, like "INSERT INTO items (id, feed_id, ...) VALUES "
+func insertInto(table string) string {
+	return "INSERT INTO " + table + " (" + strings.Join(tableColumns[table], ", ") + ") VALUES "
+}
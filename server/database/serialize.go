@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/its-mrarsikk/fedup/shared/rss"
@@ -24,6 +25,97 @@ func safeURLParse(s sql.NullString) *url.URL {
 	return u
 }
 
+// placeholders returns a parenthesized list of n "?" placeholders, like "(?,?,?)"
+func placeholders(n int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?,", n), ",") + ")"
+}
+
+// nullableString returns s, or nil (NULL) if s is ""
+func nullableString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// urlString returns the string form of u, or "" if u is nil
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+// timeString returns t in RFC3339 format, or "" if t is nil
+func timeString(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// safeTimeParse parses a time stored by timeString, returning nil if it is NULL, empty or malformed
+func safeTimeParse(s sql.NullString) *time.Time {
+	if !s.Valid {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// imageSerialize returns the values of the image_* columns of a feed. A nil img is stored as NULLs
+func imageSerialize(img *rss.Image) []any {
+	if img == nil {
+		return []any{nil, nil, nil, nil, nil, nil}
+	}
+	return []any{urlString(img.URL), img.Title, urlString(img.Link), img.Width, img.Height, img.Description}
+}
+
+// textInputSerialize returns the values of the text_input_* columns of a feed. A nil ti is stored as NULLs
+func textInputSerialize(ti *rss.TextInput) []any {
+	if ti == nil {
+		return []any{nil, nil, nil, nil}
+	}
+	return []any{ti.Title, ti.Description, ti.Name, urlString(ti.Link)}
+}
+
+// itunesFeedSerialize returns the values of the itunes_* columns of a feed. A nil it is stored as NULLs
+func itunesFeedSerialize(it *rss.ITunesFeed) []any {
+	if it == nil {
+		return []any{nil, nil, nil, nil, nil}
+	}
+	return []any{it.Author, it.Summary, urlString(it.Image), it.Explicit, it.Type}
+}
+
+// itunesItemSerialize returns the values of the itunes_* columns of an item. A nil it is stored as NULLs
+func itunesItemSerialize(it *rss.ITunesItem) []any {
+	if it == nil {
+		return []any{nil, nil, nil, nil, nil, nil, nil, nil}
+	}
+	return []any{
+		it.Author,
+		it.Summary,
+		urlString(it.Image),
+		int64(it.Duration / time.Second),
+		it.Episode,
+		it.Season,
+		it.Explicit,
+		it.EpisodeType,
+	}
+}
+
+// podcastFeedSerialize returns the values of the podcast_* columns of a feed. A nil p is stored as NULLs
+// The funding and persons of the podcast are stored in their own tables, see FundingSerialize and PersonSerialize
+func podcastFeedSerialize(p *rss.PodcastFeed) []any {
+	if p == nil {
+		return []any{nil, nil, nil}
+	}
+	return []any{p.GUID, p.Locked, p.LockedOwner}
+}
+
 func FeedSerialize(f *rss.Feed) ([]any, string) {
 	var link, fetchFrom string
 	if f.Link != nil {
@@ -37,13 +129,29 @@ func FeedSerialize(f *rss.Feed) ([]any, string) {
 		fetchFrom = ""
 	}
 
-	return []any{f.DatabaseID,
+	values := []any{nullableID(f.DatabaseID), // a new feed gets its id from the database
 		f.Title,
 		f.Description,
 		link,
 		fetchFrom,
 		f.Language,
-		f.TTL}, "(?,?,?,?,?,?,?)"
+		f.TTL}
+	values = append(values, itunesFeedSerialize(f.ITunes)...)
+	values = append(values, podcastFeedSerialize(f.Podcast)...)
+	values = append(values,
+		f.Copyright,
+		f.Publisher,
+		f.ManagingEditor,
+		f.WebMaster,
+		timeString(f.PubDate),
+		timeString(f.LastBuildDate),
+		f.Generator,
+		urlString(f.Docs),
+		f.Rating)
+	values = append(values, imageSerialize(f.Image)...)
+	values = append(values, textInputSerialize(f.TextInput)...)
+
+	return values, placeholders(len(values))
 }
 
 func FeedDeserialize(r RowScanner) (*rss.Feed, error) {
@@ -51,12 +159,29 @@ func FeedDeserialize(r RowScanner) (*rss.Feed, error) {
 	var title, description string
 	var link, fetchFrom, language sql.NullString
 	var ttl sql.NullInt64
+	var itAuthor, itSummary, itImage, itType sql.NullString
+	var itExplicit sql.NullBool
+	var podGUID, podLockedOwner sql.NullString
+	var podLocked sql.NullBool
+	var copyright, publisher, managingEditor, webMaster, pubDate, lastBuildDate, generator, docs, rating sql.NullString
+	var imgURL, imgTitle, imgLink, imgDescription sql.NullString
+	var imgWidth, imgHeight sql.NullInt64
+	var tiTitle, tiDescription, tiName, tiLink sql.NullString
 
 	var urlLink, urlFetchFrom *url.URL
 	var strLanguage string
 	var intTTL int
+	var itunes *rss.ITunesFeed
+	var podcast *rss.PodcastFeed
+	var image *rss.Image
+	var textInput *rss.TextInput
 
-	err := r.Scan(&dbid, &title, &description, &link, &fetchFrom, &language, &ttl)
+	err := r.Scan(&dbid, &title, &description, &link, &fetchFrom, &language, &ttl,
+		&itAuthor, &itSummary, &itImage, &itExplicit, &itType,
+		&podGUID, &podLocked, &podLockedOwner,
+		&copyright, &publisher, &managingEditor, &webMaster, &pubDate, &lastBuildDate, &generator, &docs, &rating,
+		&imgURL, &imgTitle, &imgLink, &imgWidth, &imgHeight, &imgDescription,
+		&tiTitle, &tiDescription, &tiName, &tiLink)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan row: %w", err)
 	}
@@ -76,22 +201,75 @@ func FeedDeserialize(r RowScanner) (*rss.Feed, error) {
 		intTTL = 0
 	}
 
+	// the itunes columns are all NULL for feeds without iTunes metadata
+	if itAuthor.Valid || itSummary.Valid || itImage.Valid || itExplicit.Valid || itType.Valid {
+		itunes = &rss.ITunesFeed{
+			Author:   itAuthor.String,
+			Summary:  itSummary.String,
+			Image:    safeURLParse(itImage),
+			Explicit: itExplicit.Bool,
+			Type:     itType.String,
+		}
+	}
+
+	if podGUID.Valid || podLocked.Valid || podLockedOwner.Valid {
+		podcast = &rss.PodcastFeed{
+			GUID:        podGUID.String,
+			Locked:      podLocked.Bool,
+			LockedOwner: podLockedOwner.String,
+		}
+	}
+
+	// an image is useless without its url
+	if u := safeURLParse(imgURL); u != nil {
+		image = &rss.Image{
+			URL:         u,
+			Title:       imgTitle.String,
+			Link:        safeURLParse(imgLink),
+			Width:       int(imgWidth.Int64),
+			Height:      int(imgHeight.Int64),
+			Description: imgDescription.String,
+		}
+	}
+
+	if tiTitle.Valid || tiDescription.Valid || tiName.Valid || tiLink.Valid {
+		textInput = &rss.TextInput{
+			Title:       tiTitle.String,
+			Description: tiDescription.String,
+			Name:        tiName.String,
+			Link:        safeURLParse(tiLink),
+		}
+	}
+
 	return &rss.Feed{
-		DatabaseID:  dbid,
-		Title:       title,
-		Description: description,
-		Link:        urlLink,
-		FetchFrom:   urlFetchFrom,
-		Language:    strLanguage,
-		TTL:         intTTL,
+		DatabaseID:     dbid,
+		Title:          title,
+		Description:    description,
+		Link:           urlLink,
+		FetchFrom:      urlFetchFrom,
+		Language:       strLanguage,
+		Copyright:      copyright.String,
+		Publisher:      publisher.String,
+		ManagingEditor: managingEditor.String,
+		WebMaster:      webMaster.String,
+		PubDate:        safeTimeParse(pubDate),
+		LastBuildDate:  safeTimeParse(lastBuildDate),
+		Generator:      generator.String,
+		Docs:           safeURLParse(docs),
+		Rating:         rating.String,
+		Image:          image,
+		TextInput:      textInput,
+		TTL:            intTTL,
+		ITunes:         itunes,
+		Podcast:        podcast,
 	}, nil
 }
 
 // ITEMS //
 
+// ItemSerialize serializes an item without its enclosures and categories, see EnclosureSerialize and CategorySerialize
 func ItemSerialize(i *rss.Item) ([]any, string) {
-	var link, pubDate, encURL, encType string
-	var encLength int
+	var link, pubDate string
 
 	if i.Link != nil {
 		link = i.Link.String()
@@ -105,49 +283,52 @@ func ItemSerialize(i *rss.Item) ([]any, string) {
 		pubDate = ""
 	}
 
-	if i.Enclosure != nil {
-		if i.Enclosure.URL != nil {
-			encURL = i.Enclosure.URL.String()
-		}
-		encType = i.Enclosure.MimeType
-		encLength = i.Enclosure.Length
-	}
-
-	return []any{
-		i.DatabaseID,
+	values := []any{
+		nullableID(i.DatabaseID), // a new item gets its id from the database
 		i.Feed.DatabaseID,
-		i.GUID,
+		nullableString(i.GUID), // NULL does not collide with the UNIQUE constraint
 		i.Title,
 		i.Description,
 		link,
 		i.Author,
 		pubDate,
 		i.Read,
-		encURL,
-		encType,
-		encLength,
-	}, "(?,?,?,?,?,?,?,?,?,?,?,?)"
+		i.Content,
+	}
+	values = append(values, itunesItemSerialize(i.ITunes)...)
+	values = append(values, nullableString(i.DescriptionText), i.GUIDIsPermaLink)
+
+	return values, placeholders(len(values))
 }
 
+// ItemDeserialize returns an item without its enclosures and categories, which are stored in their own tables
+// If feed is nil, the item gets a Feed with only its DatabaseID set
 func ItemDeserialize(r RowScanner, feed *rss.Feed) (*rss.Item, error) {
 	var dbid, feedID int
-	var guid, title, description, link, author sql.NullString
+	var guid, title, description, link, author, content, descriptionText sql.NullString
 	var pubDate sql.NullString
-	var read int
-	var enclosureURL, enclosureType sql.NullString
-	var enclosureLength sql.NullInt64
+	var read, guidIsPermaLink bool
+	var itAuthor, itSummary, itImage, itEpisodeType sql.NullString
+	var itDuration, itEpisode, itSeason sql.NullInt64
+	var itExplicit sql.NullBool
 
-	err := r.Scan(&dbid, &feedID, &guid, &title, &description, &link, &author, &pubDate, &read, &enclosureURL, &enclosureType, &enclosureLength)
+	err := r.Scan(&dbid, &feedID, &guid, &title, &description, &link, &author, &pubDate, &read, &content,
+		&itAuthor, &itSummary, &itImage, &itDuration, &itEpisode, &itSeason, &itExplicit, &itEpisodeType,
+		&descriptionText, &guidIsPermaLink)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan row: %w", err)
 	}
 
 	var (
-		urlLink, urlEnclosure *url.URL
-		timePubDate           *time.Time
-		enclosure             *rss.Enclosure
+		urlLink     *url.URL
+		timePubDate *time.Time
+		itunes      *rss.ITunesItem
 	)
 
+	if feed == nil {
+		feed = &rss.Feed{DatabaseID: feedID}
+	}
+
 	urlLink = safeURLParse(link)
 
 	if pubDate.Valid {
@@ -157,30 +338,61 @@ func ItemDeserialize(r RowScanner, feed *rss.Feed) (*rss.Item, error) {
 		}
 	}
 
-	urlEnclosure = safeURLParse(enclosureURL)
-	if urlEnclosure != nil && enclosureType.Valid && enclosureLength.Valid {
-		enclosure = &rss.Enclosure{
-			URL:      urlEnclosure,
-			MimeType: enclosureType.String,
-			Length:   int(enclosureLength.Int64),
+	// the itunes columns are all NULL for items without iTunes metadata
+	if itAuthor.Valid || itSummary.Valid || itImage.Valid || itDuration.Valid || itEpisode.Valid || itSeason.Valid || itExplicit.Valid || itEpisodeType.Valid {
+		itunes = &rss.ITunesItem{
+			Author:      itAuthor.String,
+			Summary:     itSummary.String,
+			Image:       safeURLParse(itImage),
+			Duration:    time.Duration(itDuration.Int64) * time.Second,
+			Episode:     int(itEpisode.Int64),
+			Season:      int(itSeason.Int64),
+			Explicit:    itExplicit.Bool,
+			EpisodeType: itEpisodeType.String,
 		}
 	}
 
 	return &rss.Item{
-		DatabaseID: dbid,
-		Feed:       feed,
-		GUID:       guid.String,
-		Title:      title.String,
+		DatabaseID:      dbid,
+		Feed:            feed,
+		GUID:            guid.String,
+		GUIDIsPermaLink: guidIsPermaLink,
+		Title:           title.String,
 		Description: func() string {
 			if description.Valid {
 				return description.String
 			}
 			return ""
 		}(),
-		Link:      urlLink,
-		Author:    author.String,
-		PubDate:   timePubDate,
-		Read:      read != 0, // int to bool conversion
-		Enclosure: enclosure,
+		Content:         content.String,
+		DescriptionText: descriptionText.String,
+		Link:            urlLink,
+		Author:          author.String,
+		PubDate:         timePubDate,
+		Read:            read,
+		ITunes:          itunes,
+	}, nil
+}
+
+// EnclosureSerialize serializes an enclosure of the item with the id itemID, for the enclosures table
+func EnclosureSerialize(e *rss.Enclosure, itemID int) ([]any, string) {
+	values := []any{itemID, urlString(e.URL), e.MimeType, e.Length}
+	return values, placeholders(len(values))
+}
+
+func EnclosureDeserialize(r RowScanner) (*rss.Enclosure, error) {
+	var itemID int
+	var rawURL string
+	var mimeType sql.NullString
+	var length sql.NullInt64
+
+	if err := r.Scan(&itemID, &rawURL, &mimeType, &length); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return &rss.Enclosure{
+		URL:      safeURLParse(sql.NullString{String: rawURL, Valid: true}),
+		MimeType: mimeType.String,
+		Length:   int(length.Int64),
 	}, nil
 }
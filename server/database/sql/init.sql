@@ -6,7 +6,34 @@ CREATE TABLE IF NOT EXISTS feeds (
 	link TEXT,
 	fetchFrom TEXT,
     language TEXT,
-    ttl INTEGER
+    ttl INTEGER,
+    itunes_author TEXT,
+    itunes_summary TEXT,
+    itunes_image TEXT,
+    itunes_explicit BOOLEAN,
+    itunes_type TEXT,
+    podcast_guid TEXT,
+    podcast_locked BOOLEAN,
+    podcast_locked_owner TEXT,
+    copyright TEXT,
+    publisher TEXT,
+    managing_editor TEXT,
+    web_master TEXT,
+    pub_date TEXT,
+    last_build_date TEXT,
+    generator TEXT,
+    docs TEXT,
+    rating TEXT,
+    image_url TEXT,
+    image_title TEXT,
+    image_link TEXT,
+    image_width INTEGER,
+    image_height INTEGER,
+    image_description TEXT,
+    text_input_title TEXT,
+    text_input_description TEXT,
+    text_input_name TEXT,
+    text_input_link TEXT
 );
 
 
@@ -21,8 +48,161 @@ CREATE TABLE IF NOT EXISTS items (
     author TEXT,
     pubDate TEXT,
     read BOOLEAN NOT NULL DEFAULT 0,
-    enclosure_url TEXT,
-    enclosure_type TEXT,
-    enclosure_length INTEGER,
+    content TEXT,
+    itunes_author TEXT,
+    itunes_summary TEXT,
+    itunes_image TEXT,
+    itunes_duration INTEGER,
+    itunes_episode INTEGER,
+    itunes_season INTEGER,
+    itunes_explicit BOOLEAN,
+    itunes_episode_type TEXT,
+    description_text TEXT,
+    guid_is_permalink BOOLEAN NOT NULL DEFAULT 0,
     FOREIGN KEY(feed_id) REFERENCES feeds(id)
 );
+
+
+-- Table: enclosures
+CREATE TABLE IF NOT EXISTS enclosures (
+    item_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    type TEXT,
+    length INTEGER,
+    FOREIGN KEY(item_id) REFERENCES items(id)
+);
+
+
+-- Table: categories
+-- A category belongs to either a feed or an item
+CREATE TABLE IF NOT EXISTS categories (
+    feed_id INTEGER,
+    item_id INTEGER,
+    term TEXT NOT NULL,
+    domain TEXT,
+    FOREIGN KEY(feed_id) REFERENCES feeds(id),
+    FOREIGN KEY(item_id) REFERENCES items(id)
+);
+
+CREATE INDEX IF NOT EXISTS categories_term ON categories(term COLLATE NOCASE);
+
+
+-- Table: podcast_transcripts
+CREATE TABLE IF NOT EXISTS podcast_transcripts (
+    item_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    type TEXT,
+    language TEXT,
+    rel TEXT,
+    FOREIGN KEY(item_id) REFERENCES items(id)
+);
+
+
+-- Table: podcast_chapters
+CREATE TABLE IF NOT EXISTS podcast_chapters (
+    item_id INTEGER NOT NULL UNIQUE,
+    url TEXT NOT NULL,
+    type TEXT,
+    FOREIGN KEY(item_id) REFERENCES items(id)
+);
+
+
+-- Table: podcast_persons
+CREATE TABLE IF NOT EXISTS podcast_persons (
+    feed_id INTEGER,
+    item_id INTEGER,
+    name TEXT NOT NULL,
+    role TEXT,
+    person_group TEXT,
+    img TEXT,
+    href TEXT,
+    FOREIGN KEY(feed_id) REFERENCES feeds(id),
+    FOREIGN KEY(item_id) REFERENCES items(id)
+);
+
+
+-- Table: podcast_funding
+CREATE TABLE IF NOT EXISTS podcast_funding (
+    feed_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    text TEXT,
+    FOREIGN KEY(feed_id) REFERENCES feeds(id)
+);
+
+
+-- Table: attachments
+CREATE TABLE IF NOT EXISTS attachments (
+    url TEXT PRIMARY KEY,
+    content_type TEXT,
+    etag TEXT,
+    last_modified TEXT,
+    data BLOB NOT NULL
+);
+
+
+-- Table: media
+-- A media object is identified by its item and its position in rss.Item.Media
+CREATE TABLE IF NOT EXISTS media (
+    item_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    title TEXT,
+    description TEXT,
+    PRIMARY KEY(item_id, position),
+    FOREIGN KEY(item_id) REFERENCES items(id)
+);
+
+
+-- Table: media_contents
+CREATE TABLE IF NOT EXISTS media_contents (
+    item_id INTEGER NOT NULL,
+    media_position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    type TEXT,
+    medium TEXT,
+    file_size INTEGER,
+    duration INTEGER,
+    width INTEGER,
+    height INTEGER,
+    bitrate INTEGER,
+    lang TEXT,
+    is_default BOOLEAN NOT NULL DEFAULT 0,
+    FOREIGN KEY(item_id, media_position) REFERENCES media(item_id, position)
+);
+
+
+-- Table: media_thumbnails
+CREATE TABLE IF NOT EXISTS media_thumbnails (
+    item_id INTEGER NOT NULL,
+    media_position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    FOREIGN KEY(item_id, media_position) REFERENCES media(item_id, position)
+);
+
+
+-- Table: authors
+-- An author is shared by all of their items, across feeds
+CREATE TABLE IF NOT EXISTS authors (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    email TEXT NOT NULL DEFAULT '',
+    uri TEXT NOT NULL DEFAULT '',
+    UNIQUE(name, email, uri)
+);
+
+CREATE INDEX IF NOT EXISTS authors_name ON authors(name COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS authors_email ON authors(email COLLATE NOCASE);
+
+
+-- Table: item_authors
+CREATE TABLE IF NOT EXISTS item_authors (
+    item_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY(item_id, position),
+    FOREIGN KEY(item_id) REFERENCES items(id),
+    FOREIGN KEY(author_id) REFERENCES authors(id)
+);
+
+CREATE INDEX IF NOT EXISTS item_authors_author ON item_authors(author_id);
@@ -0,0 +1,259 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// insert inserts a row of values serialized by one of the Serialize functions into table
+func insert(ctx context.Context, tx *sql.Tx, table string, values []any, placeholders string) (sql.Result, error) {
+	res, err := tx.ExecContext(ctx, insertInto(table)+placeholders, values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert into %s: %w", table, err)
+	}
+	return res, nil
+}
+
+// insertItemChildren inserts the rows of the item with the id itemID in the tables other than items
+func insertItemChildren(ctx context.Context, tx *sql.Tx, i *rss.Item, itemID int) error {
+	for _, e := range i.Enclosures {
+		values, ph := EnclosureSerialize(e, itemID)
+		if _, err := insert(ctx, tx, "enclosures", values, ph); err != nil {
+			return err
+		}
+	}
+
+	for position, p := range i.Authors {
+		authorID, err := insertAuthor(ctx, tx, p)
+		if err != nil {
+			return err
+		}
+		values, ph := ItemAuthorSerialize(itemID, authorID, position)
+		if _, err := insert(ctx, tx, "item_authors", values, ph); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range i.Categories {
+		values, ph := CategorySerialize(c, 0, itemID)
+		if _, err := insert(ctx, tx, "categories", values, ph); err != nil {
+			return err
+		}
+	}
+
+	if i.Podcast != nil {
+		for _, t := range i.Podcast.Transcripts {
+			values, ph := TranscriptSerialize(t, itemID)
+			if _, err := insert(ctx, tx, "podcast_transcripts", values, ph); err != nil {
+				return err
+			}
+		}
+		if i.Podcast.Chapters != nil {
+			values, ph := ChaptersSerialize(i.Podcast.Chapters, itemID)
+			if _, err := insert(ctx, tx, "podcast_chapters", values, ph); err != nil {
+				return err
+			}
+		}
+		for _, p := range i.Podcast.Persons {
+			values, ph := PersonSerialize(p, 0, itemID)
+			if _, err := insert(ctx, tx, "podcast_persons", values, ph); err != nil {
+				return err
+			}
+		}
+	}
+
+	for position, m := range i.Media {
+		values, ph := MediaSerialize(m, itemID, position)
+		if _, err := insert(ctx, tx, "media", values, ph); err != nil {
+			return err
+		}
+		for _, c := range m.Contents {
+			values, ph := MediaContentSerialize(c, itemID, position)
+			if _, err := insert(ctx, tx, "media_contents", values, ph); err != nil {
+				return err
+			}
+		}
+		for _, t := range m.Thumbnails {
+			values, ph := MediaThumbnailSerialize(t, itemID, position)
+			if _, err := insert(ctx, tx, "media_thumbnails", values, ph); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// insertFeedChildren inserts the rows of the feed with the id feedID in the tables other than feeds
+func insertFeedChildren(ctx context.Context, tx *sql.Tx, f *rss.Feed, feedID int) error {
+	for _, c := range f.Categories {
+		values, ph := CategorySerialize(c, feedID, 0)
+		if _, err := insert(ctx, tx, "categories", values, ph); err != nil {
+			return err
+		}
+	}
+
+	if f.Podcast != nil {
+		for _, p := range f.Podcast.Persons {
+			values, ph := PersonSerialize(p, feedID, 0)
+			if _, err := insert(ctx, tx, "podcast_persons", values, ph); err != nil {
+				return err
+			}
+		}
+		for _, fu := range f.Podcast.Funding {
+			values, ph := FundingSerialize(fu, feedID)
+			if _, err := insert(ctx, tx, "podcast_funding", values, ph); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// StoreFeed inserts a new feed, or updates the stored one with the same DatabaseID, replaces its categories and podcast persons and funding, and sets its DatabaseID
+func StoreFeed(ctx context.Context, db *sql.DB, f *rss.Feed) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	set := make([]string, 0, len(tableColumns["feeds"])-1)
+	for _, c := range tableColumns["feeds"][1:] {
+		set = append(set, c+" = excluded."+c)
+	}
+	values, ph := FeedSerialize(f)
+	res, err := tx.ExecContext(ctx, insertInto("feeds")+ph+" ON CONFLICT(id) DO UPDATE SET "+strings.Join(set, ", "), values...)
+	if err != nil {
+		return fmt.Errorf("failed to store feed %q: %w", f.Title, err)
+	}
+	id := f.DatabaseID
+	if id == 0 {
+		lastID, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get id of feed %q: %w", f.Title, err)
+		}
+		id = int(lastID)
+	}
+
+	for _, table := range []string{"categories", "podcast_persons", "podcast_funding"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE feed_id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete from %s of feed %q: %w", table, f.Title, err)
+		}
+	}
+	if err := insertFeedChildren(ctx, tx, f, id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit feed %q: %w", f.Title, err)
+	}
+	f.DatabaseID = id
+	return nil
+}
+
+// InsertItem inserts a new item with its enclosures, authors, categories, podcast metadata and media, and sets its DatabaseID
+// The Feed of the item must already be stored
+func InsertItem(ctx context.Context, db *sql.DB, i *rss.Item) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	values, ph := ItemSerialize(i)
+	res, err := insert(ctx, tx, "items", values, ph)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get id of item %q: %w", i.GUID, err)
+	}
+
+	if err := insertItemChildren(ctx, tx, i, int(id)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit item %q: %w", i.GUID, err)
+	}
+	i.DatabaseID = int(id)
+	return nil
+}
+
+// ItemExists reports whether an item with the GUID guid is stored
+func ItemExists(ctx context.Context, db *sql.DB, guid string) (bool, error) {
+	var one int
+	err := db.QueryRowContext(ctx, "SELECT 1 FROM items WHERE guid = ?", guid).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up item %q: %w", guid, err)
+	}
+	return true, nil
+}
+
+// IngestItems inserts items, as returned by rss.Stream, until it reaches one that is already stored, and returns how many were inserted.
+// Feeds list their newest items first, so the rest of the feed is not read. Items without a GUID are always inserted
+func IngestItems(ctx context.Context, db *sql.DB, items iter.Seq2[*rss.Item, error]) (int, error) {
+	n := 0
+	for item, err := range items {
+		if err != nil {
+			return n, fmt.Errorf("failed to parse item: %w", err)
+		}
+
+		if item.GUID != "" {
+			exists, err := ItemExists(ctx, db, item.GUID)
+			if err != nil {
+				return n, err
+			}
+			if exists {
+				break
+			}
+		}
+
+		if err := InsertItem(ctx, db, item); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// IngestFeed stores feed with StoreFeed and ingests its items with IngestItems, as returned together by rss.Stream
+func IngestFeed(ctx context.Context, db *sql.DB, feed *rss.Feed, items iter.Seq2[*rss.Item, error]) (int, error) {
+	if err := StoreFeed(ctx, db, feed); err != nil {
+		return 0, err
+	}
+	return IngestItems(ctx, db, items)
+}
+
+// BackfillItems inserts the items that are not stored yet, like the archive of a feed read by fetcher.Fetcher.Backfill, and returns how many were inserted.
+// Unlike IngestItems, it does not stop at the first stored item. The Feed of the items must already be stored
+func BackfillItems(ctx context.Context, db *sql.DB, items []*rss.Item) (int, error) {
+	n := 0
+	for _, item := range items {
+		exists, err := ItemExists(ctx, db, item.GUID)
+		if err != nil {
+			return n, err
+		}
+		if exists {
+			continue
+		}
+
+		if err := InsertItem(ctx, db, item); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,281 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/server/database"
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+const ingestItem = `<item><title>Episode %[1]d</title><guid>%[1]d</guid><category>Science</category>` +
+	`<enclosure url="https://example.com/%[1]d.mp3" type="audio/mpeg" length="42"/></item>`
+
+// ingestFeed returns an RSS feed with the items numbered newest down to oldest
+func ingestFeed(newest, oldest int) string {
+	var sb strings.Builder
+	sb.WriteString(`<rss version="2.0"><channel><title>Ingest</title><link>https://example.com/</link><description>Test</description>`)
+	for i := newest; i >= oldest; i-- {
+		fmt.Fprintf(&sb, ingestItem, i)
+	}
+	sb.WriteString(`</channel></rss>`)
+	return sb.String()
+}
+
+func TestIngestItems(t *testing.T) {
+	// a file, because every connection to :memory: gets its own database
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "fedup.db"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	f := &rss.Feed{DatabaseID: 1, Title: "Ingest", Description: "Test"}
+	values, ph := database.FeedSerialize(f)
+	if _, err := db.ExecContext(ctx, "INSERT INTO feeds VALUES "+ph, values...); err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	for _, c := range []struct {
+		newest, oldest, expected int
+	}{
+		{3, 1, 3},
+		// two new episodes on top of the known ones
+		{5, 1, 2},
+		{5, 1, 0},
+	} {
+		feed, items, err := rss.Stream(strings.NewReader(ingestFeed(c.newest, c.oldest)), "", nil)
+		if err != nil {
+			t.Fatalf("Stream: %s", err)
+		}
+		feed.DatabaseID = f.DatabaseID
+
+		n, err := database.IngestItems(ctx, db, items)
+		if err != nil {
+			t.Fatalf("IngestItems: %s", err)
+		}
+		if n != c.expected {
+			t.Fatalf("expected %d new items, got %d", c.expected, n)
+		}
+	}
+
+	var enclosures int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM enclosures").Scan(&enclosures); err != nil {
+		t.Fatalf("%s", err)
+	}
+	if enclosures != 5 {
+		t.Fatalf("expected 5 enclosures, got %d", enclosures)
+	}
+
+	got, err := database.ItemsByCategory(ctx, db, "Science")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if len(got) != 5 {
+		t.Fatalf("expected 5 items in the category, got %d", len(got))
+	}
+}
+
+func TestIngestFeed(t *testing.T) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "fedup.db"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// the feed is inserted on the first fetch and updated on the second, which moved it to another category
+	var id int
+	for _, c := range []struct {
+		category         string
+		newest, expected int
+	}{
+		{"Science", 2, 2},
+		{"Space", 3, 1},
+	} {
+		in := strings.Replace(ingestFeed(c.newest, 1), "<description>Test</description>", "<description>Test</description><category>"+c.category+"</category>", 1)
+		feed, items, err := rss.Stream(strings.NewReader(in), "", nil)
+		if err != nil {
+			t.Fatalf("Stream: %s", err)
+		}
+		feed.DatabaseID = id
+
+		n, err := database.IngestFeed(ctx, db, feed, items)
+		if err != nil {
+			t.Fatalf("IngestFeed: %s", err)
+		}
+		if n != c.expected {
+			t.Fatalf("expected %d new items, got %d", c.expected, n)
+		}
+		if id != 0 && feed.DatabaseID != id {
+			t.Fatalf("expected the feed to keep id %d, got %d", id, feed.DatabaseID)
+		}
+		id = feed.DatabaseID
+	}
+
+	var feeds int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM feeds").Scan(&feeds); err != nil {
+		t.Fatalf("%s", err)
+	}
+	if feeds != 1 {
+		t.Fatalf("expected 1 feed, got %d", feeds)
+	}
+
+	if got, err := database.FeedsByCategory(ctx, db, "Science"); err != nil || len(got) != 0 {
+		t.Fatalf("expected the old category to be replaced, got %+v (%v)", got, err)
+	}
+	got, err := database.FeedsByCategory(ctx, db, "Space")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if len(got) != 1 || got[0].DatabaseID != id || got[0].Title != "Ingest" {
+		t.Fatalf("expected the stored feed, got %+v", got)
+	}
+}
+
+func TestStorePodcastFeed(t *testing.T) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "fedup.db"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	in, err := os.Open("../../shared/rss/testcase/podcast.xml")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer in.Close()
+
+	feed, err := rss.ParseRSS(in)
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	// storing the feed again replaces its persons and funding instead of adding them twice
+	for range 2 {
+		if err := database.StoreFeed(ctx, db, feed); err != nil {
+			t.Fatalf("StoreFeed: %s", err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT feed_id, item_id, name, role, person_group, img, href FROM podcast_persons")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	var persons []*rss.PodcastPerson
+	for rows.Next() {
+		p, err := database.PersonDeserialize(rows)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		persons = append(persons, p)
+	}
+	rows.Close()
+	if len(persons) != 1 || persons[0].Name != "John Appleseed" || persons[0].Href.String() != "https://example.com/johnappleseed" {
+		t.Fatalf("expected the person of the feed, got %+v", persons)
+	}
+
+	funding, err := database.FundingDeserialize(db.QueryRowContext(ctx, "SELECT feed_id, url, text FROM podcast_funding WHERE feed_id = ?", feed.DatabaseID))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if funding.URL.String() != "https://www.example.com/donations" || funding.Text != "Support the show!" {
+		t.Fatalf("unexpected funding %+v", funding)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM podcast_funding").Scan(&count); err != nil {
+		t.Fatalf("%s", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 funding row, got %d", count)
+	}
+}
+
+func TestIngestWithoutGUIDs(t *testing.T) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "fedup.db"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	f := &rss.Feed{DatabaseID: 1, Title: "Ingest", Description: "Test"}
+	values, ph := database.FeedSerialize(f)
+	if _, err := db.ExecContext(ctx, "INSERT INTO feeds VALUES "+ph, values...); err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	// the same items without <guid> are recognized on the second fetch
+	in := strings.ReplaceAll(ingestFeed(2, 1), "<guid>", "<!--")
+	in = strings.ReplaceAll(in, "</guid>", "-->")
+	for _, expected := range []int{2, 0} {
+		feed, items, err := rss.Stream(strings.NewReader(in), "", nil)
+		if err != nil {
+			t.Fatalf("Stream: %s", err)
+		}
+		feed.DatabaseID = f.DatabaseID
+
+		n, err := database.IngestItems(ctx, db, items)
+		if err != nil {
+			t.Fatalf("IngestItems: %s", err)
+		}
+		if n != expected {
+			t.Fatalf("expected %d new items, got %d", expected, n)
+		}
+	}
+}
+
+func TestBackfillItems(t *testing.T) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "fedup.db"))
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	f := &rss.Feed{DatabaseID: 1, Title: "Ingest", Description: "Test"}
+	values, ph := database.FeedSerialize(f)
+	if _, err := db.ExecContext(ctx, "INSERT INTO feeds VALUES "+ph, values...); err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	// the newest items are already stored, the archive has them again on top of older ones
+	for _, c := range []struct {
+		newest, oldest, expected int
+	}{
+		{5, 4, 2},
+		{5, 1, 3},
+	} {
+		feed, err := rss.ParseRSS(strings.NewReader(ingestFeed(c.newest, c.oldest)))
+		if err != nil {
+			t.Fatalf("ParseRSS: %s", err)
+		}
+		feed.DatabaseID = f.DatabaseID
+
+		n, err := database.BackfillItems(ctx, db, feed.Items)
+		if err != nil {
+			t.Fatalf("BackfillItems: %s", err)
+		}
+		if n != c.expected {
+			t.Fatalf("expected %d items to be inserted from %d..%d, got %d", c.expected, c.newest, c.oldest, n)
+		}
+	}
+}
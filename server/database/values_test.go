@@ -18,6 +18,10 @@ const (
 
 	expectedItemTitle   = "Louisiana Students to Hear from NASA Astronauts Aboard Space Station"
 	expectedItemPubDate = "1996-12-19T16:39:57-08:00"
+	expectedItemContent = "<p>The full article</p>"
+
+	expectedITunesDuration = 64*time.Minute + 15*time.Second
+	expectedITunesEpisode  = 4
 
 	expectedEnclosureUrl    = "https://example.com"
 	expectedEnclosureLength = 42
@@ -38,12 +42,20 @@ func (m *mockRow) Scan(dest ...any) error {
 			*d = m.values[i].(string)
 		case *int:
 			*d = m.values[i].(int)
+		case *bool:
+			*d = m.values[i].(bool)
 		case *sql.NullString:
 			if v, ok := m.values[i].(string); ok {
 				*d = sql.NullString{String: v, Valid: true}
 			} else {
 				*d = sql.NullString{Valid: false}
 			}
+		case *sql.NullBool:
+			if v, ok := m.values[i].(bool); ok {
+				*d = sql.NullBool{Bool: v, Valid: true}
+			} else {
+				*d = sql.NullBool{Valid: false}
+			}
 		case *sql.NullInt64:
 			if v, ok := m.values[i].(int64); ok {
 				*d = sql.NullInt64{Int64: v, Valid: true}
@@ -97,7 +109,12 @@ func TestFeedSerialize(t *testing.T) {
 }
 
 func TestFeedDeserialize(t *testing.T) {
-	m := mockRow{values: []any{4, expectedFeedTitle, "Test", expectedFeedLink, nil, "", int64(expectedFeedTTL)}}
+	m := mockRow{values: []any{4, expectedFeedTitle, "Test", expectedFeedLink, nil, "", int64(expectedFeedTTL),
+		nil, nil, nil, nil, nil,
+		nil, nil, nil,
+		nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, nil, nil, nil, nil,
+		nil, nil, nil, nil}}
 
 	f, err := database.FeedDeserialize(&m)
 	if err != nil {
@@ -115,6 +132,58 @@ func TestFeedDeserialize(t *testing.T) {
 	if f.TTL != expectedFeedTTL {
 		t.Fatalf("expected TTL %d, got %d", expectedFeedTTL, f.TTL)
 	}
+
+	if f.ITunes != nil {
+		t.Fatalf("expected no iTunes metadata, got %+v", f.ITunes)
+	}
+}
+
+func TestFeedChannelMetadata(t *testing.T) {
+	uImage, err := url.Parse(expectedEnclosureUrl)
+	if err != nil {
+		panic(err)
+	}
+
+	lastBuildDate, err := time.Parse(time.RFC3339, expectedItemPubDate)
+	if err != nil {
+		panic(err)
+	}
+
+	f := &rss.Feed{
+		Title:         expectedFeedTitle,
+		Generator:     "Blosxom 2.1.2",
+		LastBuildDate: &lastBuildDate,
+		Image:         &rss.Image{URL: uImage, Width: 144, Height: 120},
+	}
+
+	placeholders, _ := database.FeedSerialize(f)
+	if placeholders[20].(string) != expectedItemPubDate || placeholders[24].(string) != expectedEnclosureUrl || placeholders[30] != nil {
+		t.Fatalf("unexpected values %v", placeholders)
+	}
+
+	m := mockRow{values: []any{4, expectedFeedTitle, "Test", nil, nil, nil, nil,
+		nil, nil, nil, nil, nil,
+		nil, nil, nil,
+		nil, nil, nil, nil, nil, expectedItemPubDate, "Blosxom 2.1.2", nil, nil,
+		expectedEnclosureUrl, nil, nil, 144, 120, nil,
+		nil, nil, nil, nil}}
+
+	got, err := database.FeedDeserialize(&m)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if got.Generator != f.Generator || got.LastBuildDate == nil || !got.LastBuildDate.Equal(lastBuildDate) || got.PubDate != nil {
+		t.Fatalf("unexpected channel metadata %+v", got)
+	}
+
+	if got.Image == nil || got.Image.URL.String() != expectedEnclosureUrl || got.Image.Width != 144 || got.Image.Height != 120 {
+		t.Fatalf("unexpected image %+v", got.Image)
+	}
+
+	if got.TextInput != nil {
+		t.Fatalf("expected no textInput, got %+v", got.TextInput)
+	}
 }
 
 func TestItemSerialize(t *testing.T) {
@@ -137,10 +206,11 @@ func TestItemSerialize(t *testing.T) {
 		Feed:       f,
 		Title:      expectedItemTitle,
 		PubDate:    &tPubDate,
+		Content:    expectedItemContent,
 	}
 
 	placeholders, _ := database.ItemSerialize(i)
-	gotTitle, gotPubDate := placeholders[3].(string), placeholders[7].(string)
+	gotTitle, gotPubDate, gotContent := placeholders[3].(string), placeholders[7].(string), placeholders[9].(string)
 
 	if gotTitle != expectedItemTitle {
 		t.Fatalf("expected item title %q, got %q", expectedItemTitle, gotTitle)
@@ -149,6 +219,10 @@ func TestItemSerialize(t *testing.T) {
 	if gotPubDate != expectedItemPubDate {
 		t.Fatalf("expected item pubDate %q, got %q", expectedItemPubDate, gotPubDate)
 	}
+
+	if gotContent != expectedItemContent {
+		t.Fatalf("expected item content %q, got %q", expectedItemContent, gotContent)
+	}
 }
 
 func TestItemDeserialize(t *testing.T) {
@@ -157,12 +231,8 @@ func TestItemDeserialize(t *testing.T) {
 		panic(err)
 	}
 
-	uEnclosureURL, err := url.Parse(expectedEnclosureUrl)
-	if err != nil {
-		panic(err)
-	}
-
-	m := mockRow{values: []any{6, 1, nil, expectedItemTitle, nil, nil, nil, expectedItemPubDate, 1, expectedEnclosureUrl, "text/plain", expectedEnclosureLength}}
+	m := mockRow{values: []any{6, 1, nil, expectedItemTitle, nil, nil, nil, expectedItemPubDate, true, expectedItemContent,
+		nil, nil, nil, nil, nil, nil, nil, nil, "The full article", false}}
 	f := &rss.Feed{DatabaseID: 1}
 
 	i, err := database.ItemDeserialize(&m, f)
@@ -174,22 +244,140 @@ func TestItemDeserialize(t *testing.T) {
 		t.Fatalf("expected item title %q, got %q", expectedItemTitle, i.Title)
 	}
 
+	if i.Content != expectedItemContent || i.DescriptionText != "The full article" {
+		t.Fatalf("expected item content %q, got %q and text %q", expectedItemContent, i.Content, i.DescriptionText)
+	}
+
 	if *i.PubDate != tPubDate {
 		t.Fatalf("expected item pubdate %s, got %s",
 			expectedItemPubDate, i.PubDate.Format(time.RFC3339))
 	}
 
-	if i.Enclosure == nil {
-		t.Fatal("enclosure is nil")
+	if i.Feed != f {
+		t.Fatalf("expected item feed %p, got %p", f, i.Feed)
 	}
+}
+
+func TestEnclosureDeserialize(t *testing.T) {
+	uEnclosureURL, err := url.Parse(expectedEnclosureUrl)
+	if err != nil {
+		panic(err)
+	}
+
+	m := mockRow{values: []any{6, expectedEnclosureUrl, "text/plain", expectedEnclosureLength}}
 
-	if *i.Enclosure.URL != *uEnclosureURL {
+	e, err := database.EnclosureDeserialize(&m)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if *e.URL != *uEnclosureURL {
 		t.Fatalf("expected enclosure url %q, got %q",
-			expectedEnclosureUrl, i.Enclosure.URL.String())
+			expectedEnclosureUrl, e.URL.String())
 	}
 
-	if i.Enclosure.Length != expectedEnclosureLength { // leb
+	if e.Length != expectedEnclosureLength { // leb
 		t.Fatalf("expected enclosure length %d, got %d",
-			expectedEnclosureLength, i.Enclosure.Length)
+			expectedEnclosureLength, e.Length)
+	}
+}
+
+func TestItemITunes(t *testing.T) {
+	f := &rss.Feed{DatabaseID: 1}
+	i := &rss.Item{
+		Feed: f,
+		ITunes: &rss.ITunesItem{
+			Duration: expectedITunesDuration,
+			Episode:  expectedITunesEpisode,
+			Explicit: true,
+		},
+	}
+
+	placeholders, _ := database.ItemSerialize(i)
+	gotDuration, gotEpisode, gotExplicit := placeholders[13].(int64), placeholders[14].(int), placeholders[16].(bool)
+
+	if gotDuration != int64(expectedITunesDuration/time.Second) {
+		t.Fatalf("expected duration %d seconds, got %d", int64(expectedITunesDuration/time.Second), gotDuration)
+	}
+
+	if gotEpisode != expectedITunesEpisode || !gotExplicit {
+		t.Fatalf("expected episode %d and explicit, got %d and %t", expectedITunesEpisode, gotEpisode, gotExplicit)
+	}
+
+	m := mockRow{values: []any{6, 1, nil, nil, nil, nil, nil, nil, false, nil,
+		nil, nil, nil, int64(expectedITunesDuration / time.Second), expectedITunesEpisode, nil, true, "full", nil, false}}
+
+	got, err := database.ItemDeserialize(&m, f)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if got.ITunes == nil {
+		t.Fatal("iTunes metadata is nil")
+	}
+
+	if got.ITunes.Duration != expectedITunesDuration || got.ITunes.Episode != expectedITunesEpisode || !got.ITunes.Explicit {
+		t.Fatalf("unexpected iTunes metadata %+v", got.ITunes)
+	}
+}
+
+func TestPersonSerialize(t *testing.T) {
+	uImage, err := url.Parse(expectedEnclosureUrl)
+	if err != nil {
+		panic(err)
+	}
+
+	p := &rss.PodcastPerson{Name: "Alice Brown", Role: "guest", Group: "cast", Image: uImage}
+
+	placeholders, ph := database.PersonSerialize(p, 0, 6)
+	if ph != "(?,?,?,?,?,?,?)" {
+		t.Fatalf("unexpected placeholders %q", ph)
+	}
+
+	if placeholders[0] != nil {
+		t.Fatalf("expected NULL feed_id for a person of an item, got %v", placeholders[0])
+	}
+
+	if placeholders[1].(int) != 6 || placeholders[2].(string) != p.Name || placeholders[5].(string) != expectedEnclosureUrl {
+		t.Fatalf("unexpected values %v", placeholders)
+	}
+
+	m := mockRow{values: []any{nil, 6, p.Name, p.Role, p.Group, expectedEnclosureUrl, nil}}
+	got, err := database.PersonDeserialize(&m)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if got.Name != p.Name || got.Role != p.Role || got.Image.String() != expectedEnclosureUrl || got.Href != nil {
+		t.Fatalf("unexpected person %+v", got)
+	}
+}
+
+func TestMediaContentSerialize(t *testing.T) {
+	uContent, err := url.Parse(expectedEnclosureUrl)
+	if err != nil {
+		panic(err)
+	}
+
+	c := &rss.MediaContent{URL: uContent, MimeType: "video/mp4", Duration: 61500 * time.Millisecond, Height: 1080, IsDefault: true}
+
+	// durations are stored in seconds, like itunes_duration
+	placeholders, _ := database.MediaContentSerialize(c, 6, 1)
+	if placeholders[1].(int) != 1 || placeholders[6].(int64) != 61 {
+		t.Fatalf("unexpected values %v", placeholders)
+	}
+
+	m := mockRow{values: []any{6, 1, expectedEnclosureUrl, "video/mp4", "video", nil, int64(61), nil, 1080, nil, nil, true}}
+	got, position, err := database.MediaContentDeserialize(&m)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if position != 1 {
+		t.Fatalf("expected position 1, got %d", position)
+	}
+
+	if got.Duration != 61*time.Second || got.Height != c.Height || !got.IsDefault || got.URL.String() != expectedEnclosureUrl {
+		t.Fatalf("unexpected rendition %+v", got)
 	}
 }
@@ -0,0 +1,82 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// The largest attachment that will be downloaded. Transcripts and chapters files are text, so this is generous
+const maxAttachmentSize = 10 << 20
+
+var ErrAttachmentTooLarge = fmt.Errorf("attachment is larger than %d bytes", maxAttachmentSize)
+
+// FetchAttachment downloads a file referenced by an item, like a podcast transcript or chapters file (see rss.PodcastItem.Files).
+// If cached is not nil, the request is conditional on its ETag and Last-Modified, and cached is returned as-is if the file has not changed.
+func (f *Fetcher) FetchAttachment(u *url.URL, cached *rss.Attachment) (*rss.Attachment, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w on attachment %q", err, u.String())
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if !cached.LastModified.IsZero() {
+			req.Header.Set("If-Modified-Since", cached.LastModified.Format(http.TimeFormat))
+		}
+	}
+
+	if f.client == nil {
+		return nil, errors.New("fetcher.client is nil")
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attachment %q: %w", u.String(), err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// read one byte past the limit to tell a file of exactly maxAttachmentSize from a larger one
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxAttachmentSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment %q: %w", u.String(), err)
+		}
+		if len(data) > maxAttachmentSize {
+			return nil, fmt.Errorf("failed to read attachment %q: %w", u.String(), ErrAttachmentTooLarge)
+		}
+
+		a := &rss.Attachment{
+			URL:         u,
+			ContentType: resp.Header.Get("Content-Type"),
+			Data:        data,
+			ETag:        resp.Header.Get("ETag"),
+		}
+		if resp_lm := resp.Header.Get("Last-Modified"); resp_lm != "" {
+			lm, err := time.Parse(http.TimeFormat, resp_lm)
+			if err != nil {
+				log.Printf("failed to parse Last-Modified value %q on attachment %q (non-fatal)", resp_lm, u.String())
+			} else {
+				a.LastModified = lm
+			}
+		}
+		return a, nil
+	case http.StatusNotModified:
+		if cached == nil {
+			return nil, fmt.Errorf("got 304 Not Modified on attachment %q without a cached copy", u.String())
+		}
+		return cached, nil
+	default:
+		return nil, fmt.Errorf("got unhappy status code on attachment %q: %s", u.String(), resp.Status)
+	}
+}
@@ -0,0 +1,74 @@
+package fetcher_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/server/fetcher"
+)
+
+const sampleTranscript = "1\n00:00:00,000 --> 00:00:02,000\nWelcome to the show\n"
+
+func TestFetchAttachment(t *testing.T) {
+	t.Parallel()
+
+	const etag = `"t1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/srt")
+		fmt.Fprint(w, sampleTranscript)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL + "/transcript.srt")
+	if err != nil {
+		panic(err)
+	}
+
+	f := fetcher.NewFetcher()
+	a, err := f.FetchAttachment(u, nil)
+	if err != nil {
+		t.Fatalf("FetchAttachment: %s", err)
+	}
+
+	if string(a.Data) != sampleTranscript || a.ContentType != "application/srt" || a.ETag != etag {
+		t.Fatalf("unexpected attachment %+v", a)
+	}
+
+	cached, err := f.FetchAttachment(u, a)
+	if err != nil {
+		t.Fatalf("FetchAttachment (cached): %s", err)
+	}
+
+	if cached != a {
+		t.Fatalf("expected the cached attachment to be returned on 304, got %+v", cached)
+	}
+}
+
+func TestFetchAttachmentTooLarge(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("a", 10<<20+1))
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		panic(err)
+	}
+
+	f := fetcher.NewFetcher()
+	if _, err := f.FetchAttachment(u, nil); !errors.Is(err, fetcher.ErrAttachmentTooLarge) {
+		t.Fatalf("expected ErrAttachmentTooLarge, got %v", err)
+	}
+}
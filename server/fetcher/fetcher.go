@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"net"
 	"net/http"
 	"net/url"
@@ -14,20 +15,32 @@ import (
 
 	"github.com/its-mrarsikk/fedup/shared"
 	"github.com/its-mrarsikk/fedup/shared/rss"
+	"github.com/its-mrarsikk/fedup/shared/sanitize"
 )
 
 var userAgent = fmt.Sprintf("fedupd/%s (+%s)", shared.Version, shared.ContactEmail)
 
 type FetchFeed struct { // dont got a good name for this one
-	url     *url.URL
-	ttl     time.Duration
-	ticker  *time.Ticker
-	tickCh  chan time.Time
-	done    chan struct{}
-	fetcher *Fetcher
+	url    *url.URL
+	ttl    time.Duration
+	ticker *time.Ticker
+	tickCh chan time.Time
+	// requests a fetch outside the schedule, see refetch. Unlike tickCh it is never replaced, so it is safe to send to from other goroutines
+	refetchCh chan struct{}
+	done      chan struct{}
+	fetcher   *Fetcher
 
 	last_etag     string
 	last_modified time.Time
+	// the <skipHours> and <skipDays> of the last fetch, see rss.SkipsAt
+	skip_hours []int
+	skip_days  []time.Weekday
+	// the GUIDs of the items fetched so far, to stop reading the feed at the first item that is not new
+	known_guids map[string]struct{}
+	// guards known_guids, which WebSub notifications read and update too
+	mu sync.Mutex
+	// whether the older documents of the feed are read on the next fetch, see AddNewFeed
+	backfill bool
 }
 
 type Fetcher struct {
@@ -36,6 +49,16 @@ type Fetcher struct {
 	started bool
 	Ch      *FetcherChannels
 	client  *http.Client
+	// The options fetched feeds are parsed with. nil is lenient, and the problems of each feed are in its Warnings
+	ParseOptions *rss.ParseOptions
+	// The policy the Description and Content of fetched items are sanitized with, which also sets their DescriptionText. nil leaves items as published
+	Sanitizer *sanitize.Policy
+	// The number of older documents of a paged or archived feed (RFC 5005) that are read when a feed added with AddNewFeed is fetched for the first time. 0 reads only the newest
+	PagingDepth int
+	// The subscriber that feeds with a WebSub hub are subscribed with, see EnableWebSub. nil only polls feeds
+	WebSub *WebSub
+	// The subscriber that feeds with an rssCloud are registered with, see EnableRSSCloud. nil only polls feeds
+	RSSCloud *RSSCloud
 }
 
 type FetcherChannels struct {
@@ -45,22 +68,25 @@ type FetcherChannels struct {
 
 var ErrNewTTL = errors.New("ttl changed")
 
-// NewFetcher constructs and returns a Fetcher with initialized FetchedFeeds and Err channels, and an http.Client.
+// The PagingDepth of a new Fetcher
+const defaultPagingDepth = 10
+
+// NewFetcher constructs and returns a Fetcher with initialized FetchedFeeds and Err channels, an http.Client, the default sanitize.Policy and PagingDepth.
 func NewFetcher() *Fetcher {
 	client := &http.Client{Timeout: 15 * time.Second}
-	return &Fetcher{Ch: &FetcherChannels{FetchedFeeds: make(chan *rss.Feed, 6), Err: make(chan error, 2)}, client: client}
+	return NewFetcherWithClient(client)
 }
 
 // NewFetcherWithClient constructs and returns a Fetcher with the provided Client.
 func NewFetcherWithClient(client *http.Client) *Fetcher {
-	return &Fetcher{Ch: &FetcherChannels{FetchedFeeds: make(chan *rss.Feed, 6), Err: make(chan error, 2)}, client: client}
+	return &Fetcher{Ch: &FetcherChannels{FetchedFeeds: make(chan *rss.Feed, 6), Err: make(chan error, 2)}, client: client, Sanitizer: sanitize.NewPolicy(), PagingDepth: defaultPagingDepth}
 }
 
-// fetch requests the feed from the url. nil is returned for io.ReadCloser if the feed is cached.
-func (ff *FetchFeed) fetch() (io.ReadCloser, error) {
+// fetch requests the feed from the url and returns its body and Content-Type. nil is returned for io.ReadCloser if the feed is cached.
+func (ff *FetchFeed) fetch() (io.ReadCloser, string, error) {
 	req, err := http.NewRequest(http.MethodGet, ff.url.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w on feed %q", err, ff.url.String())
+		return nil, "", fmt.Errorf("failed to create request: %w on feed %q", err, ff.url.String())
 	}
 
 	req.Header.Set("User-Agent", userAgent)
@@ -72,23 +98,23 @@ func (ff *FetchFeed) fetch() (io.ReadCloser, error) {
 	}
 
 	if ff.fetcher == nil {
-		return nil, errors.New("fetcher is nil")
+		return nil, "", errors.New("fetcher is nil")
 	}
 	if ff.fetcher.client == nil {
-		return nil, errors.New("fetcher.client is nil")
+		return nil, "", errors.New("fetcher.client is nil")
 	}
 
 	resp, err := ff.fetcher.client.Do(req)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, fmt.Errorf("timed out: %w", err)
+			return nil, "", fmt.Errorf("timed out: %w", err)
 		}
 
 		if ne, ok := err.(net.Error); ok && ne.Timeout() {
-			return nil, fmt.Errorf("timed out: %w", err)
+			return nil, "", fmt.Errorf("timed out: %w", err)
 		}
 
-		return nil, err
+		return nil, "", err
 	}
 
 	switch resp.StatusCode {
@@ -104,18 +130,51 @@ func (ff *FetchFeed) fetch() (io.ReadCloser, error) {
 				ff.last_modified = lm
 			}
 		}
-		return resp.Body, nil
+		return resp.Body, resp.Header.Get("Content-Type"), nil
 	case http.StatusNotModified:
 		resp.Body.Close()
-		return nil, nil
+		return nil, "", nil
 	default:
 		resp.Body.Close()
-		return nil, fmt.Errorf("got unhappy status code on feed %q: %s", ff.url.String(), resp.Status)
+		return nil, "", fmt.Errorf("got unhappy status code on feed %q: %s", ff.url.String(), resp.Status)
 	}
 }
 
+// parse parses the feed in r, fetched from u, with the items up to the first one in known, and sanitizes them
+func (f *Fetcher) parse(r io.Reader, contentType string, u *url.URL, known map[string]struct{}) (*rss.Feed, error) {
+	// relative URLs in the feed are resolved against the URL it was fetched from
+	var opts rss.ParseOptions
+	if f.ParseOptions != nil {
+		opts = *f.ParseOptions
+	}
+	opts.BaseURL = u
+
+	parsed, items, err := rss.Stream(r, contentType, &opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed %q: %w", u.String(), err)
+	}
+
+	// feeds list their newest items first, so everything after a known item is known too
+	for item, err := range items {
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse feed %q: %w", u.String(), err)
+		}
+		if _, ok := known[item.GUID]; ok {
+			break
+		}
+		if p := f.Sanitizer; p != nil {
+			item.Description = p.Sanitize(item.Description)
+			item.Content = p.Sanitize(item.Content)
+			item.DescriptionText = sanitize.Text(item.Description)
+		}
+		parsed.Items = append(parsed.Items, item)
+	}
+
+	return parsed, nil
+}
+
 func (ff *FetchFeed) fetchAndParse() error {
-	r, err := ff.fetch()
+	r, contentType, err := ff.fetch()
 	if err != nil {
 		return fmt.Errorf("failed to fetch feed %q: %w", ff.url.String(), err)
 	}
@@ -124,15 +183,43 @@ func (ff *FetchFeed) fetchAndParse() error {
 	}
 	defer r.Close()
 
-	parsed, err := rss.ParseRSS(r)
-
+	// the body is read without holding ff.mu, so a slow feed does not hold up WebSub notifications
+	parsed, err := ff.fetcher.parse(r, contentType, ff.url, ff.knownGUIDs())
 	if err != nil {
-		return fmt.Errorf("failed to parse feed %q: %w", ff.url.String(), err)
+		return err
+	}
+	// the self link is the topic of the feed on its hub, and may differ from the URL it was fetched from
+	topic := parsed.FetchFrom
+	if topic == nil {
+		topic = ff.url
 	}
-
 	parsed.FetchFrom = ff.url
+
+	// the backlog of paged and archived feeds is only read once, the items of later fetches are all in the newest document
+	if ff.backfill {
+		ff.backfill = false
+		if ff.fetcher.PagingDepth > 0 {
+			if _, err := ff.fetcher.followPages(context.Background(), parsed, ff.fetcher.PagingDepth); err != nil {
+				log.Printf("failed to read the older pages of feed %q: %s (non-fatal)", ff.url.String(), err)
+			}
+		}
+	}
+
+	// a notification may have delivered some of the items since the snapshot was taken
+	ff.mu.Lock()
+	parsed.Items = ff.remember(parsed.Items)
+	ff.mu.Unlock()
+
+	ff.skip_hours, ff.skip_days = parsed.SkipHours, parsed.SkipDays
 	ff.fetcher.Ch.FetchedFeeds <- parsed
 
+	if ws := ff.fetcher.WebSub; ws != nil && parsed.Hub != nil {
+		ws.subscribeFeed(ff, parsed.Hub, topic)
+	}
+	if rc := ff.fetcher.RSSCloud; rc != nil && parsed.Cloud != nil {
+		rc.registerFeed(ff, parsed.Cloud)
+	}
+
 	if parsed.TTL != 0 && !(time.Duration(parsed.TTL)*time.Minute == ff.ttl) {
 		ff.ttl = time.Duration(parsed.TTL) * time.Minute
 		return ErrNewTTL
@@ -141,6 +228,39 @@ func (ff *FetchFeed) fetchAndParse() error {
 	return nil
 }
 
+// knownGUIDs returns a copy of the known GUIDs of the feed, to read it without holding ff.mu
+func (ff *FetchFeed) knownGUIDs() map[string]struct{} {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	return maps.Clone(ff.known_guids)
+}
+
+// remember adds the GUIDs of items to the known GUIDs of the feed, and returns the items that were not known yet. ff.mu must be held
+func (ff *FetchFeed) remember(items []*rss.Item) []*rss.Item {
+	if ff.known_guids == nil {
+		ff.known_guids = make(map[string]struct{})
+	}
+	fresh := items[:0]
+	for _, item := range items {
+		if item.GUID != "" {
+			if _, ok := ff.known_guids[item.GUID]; ok {
+				continue
+			}
+			ff.known_guids[item.GUID] = struct{}{}
+		}
+		fresh = append(fresh, item)
+	}
+	return fresh
+}
+
+// refetch makes the feed fetch now rather than at the next tick, unless a fetch is waiting already. Skipped hours and days are ignored
+func (ff *FetchFeed) refetch() {
+	select {
+	case ff.refetchCh <- struct{}{}:
+	default:
+	}
+}
+
 func (ff *FetchFeed) watch() {
 	for {
 		select {
@@ -150,25 +270,41 @@ func (ff *FetchFeed) watch() {
 				ff.ticker = nil
 			}
 			return
-		case <-ff.tickCh:
-			err := ff.fetchAndParse()
-			if err != nil {
-				if errors.Is(err, ErrNewTTL) {
-					log.Printf("Fetcher %q: discovered new TTL %s, restarting", ff.url.String(), ff.ttl)
-					if ff.ticker != nil {
-						ff.ticker.Stop()
-						ff.ticker = nil
-					}
-					startFeed(ff)
-					return
+		case v := <-ff.tickCh:
+			// the first tick (a zero time) always fetches, the schedule is not known before that
+			if !v.IsZero() && rss.SkipsAt(v, ff.skip_hours, ff.skip_days) {
+				continue
+			}
+		case <-ff.refetchCh:
+		}
+
+		err := ff.fetchAndParse()
+		if err != nil {
+			if errors.Is(err, ErrNewTTL) {
+				log.Printf("Fetcher %q: discovered new TTL %s, restarting", ff.url.String(), ff.ttl)
+				if ff.ticker != nil {
+					ff.ticker.Stop()
+					ff.ticker = nil
 				}
-				go func(e error) { ff.fetcher.Ch.Err <- e }(err)
+				startFeed(ff)
+				return
 			}
+			go func(e error) { ff.fetcher.Ch.Err <- e }(err)
 		}
 	}
 }
 
 func (f *Fetcher) AddFeed(rawurl string, optTtl *time.Duration) error {
+	return f.addFeed(rawurl, optTtl, false)
+}
+
+// AddNewFeed adds a feed that was just subscribed to, like AddFeed, and reads up to PagingDepth of its older documents on the first fetch.
+// Feeds that were already stored are added with AddFeed, or they would be backfilled every time the Fetcher is started again
+func (f *Fetcher) AddNewFeed(rawurl string, optTtl *time.Duration) error {
+	return f.addFeed(rawurl, optTtl, true)
+}
+
+func (f *Fetcher) addFeed(rawurl string, optTtl *time.Duration, backfill bool) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -187,7 +323,7 @@ func (f *Fetcher) AddFeed(rawurl string, optTtl *time.Duration) error {
 		ttl = 60 * time.Minute
 	}
 
-	ff := &FetchFeed{url: parsedURL, ttl: ttl, fetcher: f}
+	ff := &FetchFeed{url: parsedURL, ttl: ttl, refetchCh: make(chan struct{}, 1), fetcher: f, backfill: backfill}
 	if f.started {
 		ff.ticker = time.NewTicker(ttl)
 		go ff.watch()
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/its-mrarsikk/fedup/server/fetcher"
+	"github.com/its-mrarsikk/fedup/shared/rss"
 )
 
 const (
@@ -47,6 +49,156 @@ func TestFetchAndParse(t *testing.T) {
 	}
 }
 
+func TestUnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><body>not a feed</body></html>")
+	}))
+	defer srv.Close()
+
+	ttlVar := ttl
+	f := fetcher.NewFetcher()
+	f.AddFeed(srv.URL, &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	select {
+	case feed := <-f.Ch.FetchedFeeds:
+		t.Fatalf("unexpected feed: %+v", feed)
+	case err := <-f.Ch.Err:
+		var formatErr *rss.UnknownFormatError
+		if !errors.As(err, &formatErr) {
+			t.Fatalf("expected *rss.UnknownFormatError, got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (no response after 3s)")
+	}
+}
+
+func TestCharsetFromHeader(t *testing.T) {
+	t.Parallel()
+
+	// "Новости" in windows-1251, without an encoding declaration
+	const title = "\xcd\xee\xe2\xee\xf1\xf2\xe8"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=windows-1251")
+		fmt.Fprint(w, `<rss version="2.0"><channel><title>`+title+`</title><link>b</link><description>c</description></channel></rss>`)
+	}))
+	defer srv.Close()
+
+	ttlVar := ttl
+	f := fetcher.NewFetcher()
+	f.AddFeed(srv.URL, &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	select {
+	case feed := <-f.Ch.FetchedFeeds:
+		if feed.Title != "Новости" {
+			t.Fatalf("expected feed title %q, got %q", "Новости", feed.Title)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (no response after 3s)")
+	case err := <-f.Ch.Err:
+		t.Fatalf("%s", err)
+	}
+}
+
+func TestSanitizesItems(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Replace(sampleFeed, "</channel>",
+			`<item><title>x</title><description><![CDATA[<p onclick="steal()">Hi <b>there</b></p><script>steal()</script>]]></description></item></channel>`, 1))
+	}))
+	defer srv.Close()
+
+	ttlVar := ttl
+	f := fetcher.NewFetcher()
+	f.AddFeed(srv.URL, &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	select {
+	case feed := <-f.Ch.FetchedFeeds:
+		item := feed.Items[0]
+		if item.Description != "<p>Hi <b>there</b></p>" || item.DescriptionText != "Hi there" {
+			t.Fatalf("expected a sanitized description, got %q and %q", item.Description, item.DescriptionText)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (no response after 3s)")
+	case err := <-f.Ch.Err:
+		t.Fatalf("%s", err)
+	}
+}
+
+func TestResolvesRelativeURLs(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Replace(sampleFeed, "</channel>", `<item><title>x</title><link>/posts/1</link></item></channel>`, 1))
+	}))
+	defer srv.Close()
+
+	ttlVar := ttl
+	f := fetcher.NewFetcher()
+	f.AddFeed(srv.URL+"/feed.xml", &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	select {
+	case feed := <-f.Ch.FetchedFeeds:
+		// the link of the sample feed is "b", so the URL of the feed is the base
+		if feed.Link.String() != srv.URL+"/b" || feed.Items[0].Link.String() != srv.URL+"/posts/1" {
+			t.Fatalf("expected absolute links, got %q and %q", feed.Link, feed.Items[0].Link)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (no response after 3s)")
+	case err := <-f.Ch.Err:
+		t.Fatalf("%s", err)
+	}
+}
+
+func TestStrictParsing(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Replace(sampleFeed, "</channel>", "<ttl>soon</ttl></channel>", 1))
+	}))
+	defer srv.Close()
+
+	ttlVar := ttl
+	f := fetcher.NewFetcher()
+	f.ParseOptions = &rss.ParseOptions{Strict: true}
+	f.AddFeed(srv.URL, &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	select {
+	case feed := <-f.Ch.FetchedFeeds:
+		t.Fatalf("expected the feed to be rejected, got %+v", feed)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (no response after 3s)")
+	case err := <-f.Ch.Err:
+		var strictErr *rss.StrictError
+		if !errors.As(err, &strictErr) {
+			t.Fatalf("expected a StrictError, got %v", err)
+		}
+	}
+}
+
 func TestLastModified(t *testing.T) {
 	t.Parallel()
 
@@ -100,6 +252,42 @@ func TestLastModified(t *testing.T) {
 	}
 }
 
+func TestOnlyNewItems(t *testing.T) {
+	t.Parallel()
+
+	const item = `<item><title>%[1]s</title><guid>%[1]s</guid></item>`
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		items := fmt.Sprintf(item, "2") + fmt.Sprintf(item, "1")
+		if atomic.AddInt32(&calls, 1) > 1 {
+			items = fmt.Sprintf(item, "3") + items
+		}
+		fmt.Fprint(w, strings.Replace(sampleFeed, "</channel>", items+"</channel>", 1))
+	}))
+	defer srv.Close()
+
+	ttlVar := ttl
+	f := fetcher.NewFetcher()
+	f.AddFeed(srv.URL, &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	for _, expected := range []int{2, 1} {
+		select {
+		case feed := <-f.Ch.FetchedFeeds:
+			if len(feed.Items) != expected {
+				t.Fatalf("expected %d new items, got %d", expected, len(feed.Items))
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out (no response after 3s)")
+		case err := <-f.Ch.Err:
+			t.Fatalf("%s", err)
+		}
+	}
+}
+
 func TestETag(t *testing.T) {
 	t.Parallel()
 
@@ -229,6 +417,50 @@ func TestCached304(t *testing.T) {
 	}
 }
 
+func TestRespectsSkipHours(t *testing.T) {
+	t.Parallel()
+
+	var hours strings.Builder
+	for h := range 24 {
+		fmt.Fprintf(&hours, "<hour>%d</hour>", h)
+	}
+	skipFeed := strings.Replace(sampleFeed, "</channel>", "<skipHours>"+hours.String()+"</skipHours></channel>", 1)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, skipFeed)
+	}))
+	defer srv.Close()
+
+	f := fetcher.NewFetcher()
+	var ttlVar = ttl
+	if err := f.AddFeed(srv.URL, &ttlVar); err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+	if err := f.Start(); err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	select {
+	case feed := <-f.Ch.FetchedFeeds:
+		if len(feed.SkipHours) != 24 {
+			t.Fatalf("expected 24 skipHours, got %v", feed.SkipHours)
+		}
+	case err := <-f.Ch.Err:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for first feed")
+	}
+
+	// every hour is skipped, so only the first fetch happens
+	time.Sleep(3 * ttl)
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+}
+
 func TestTimeout(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,104 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// fetchPage requests a document of a paged or archived feed and returns its body and Content-Type. Archive documents do not change, so the request is not conditional
+func (f *Fetcher) fetchPage(ctx context.Context, u *url.URL) (io.ReadCloser, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w on page %q", err, u.String())
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	if f.client == nil {
+		return nil, "", errors.New("fetcher.client is nil")
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch page %q: %w", u.String(), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("got unhappy status code on page %q: %s", u.String(), resp.Status)
+	}
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
+// followPages reads the older documents of feed, following rss.Paging.Older, and appends their items to the Items of feed. It returns the number of documents read.
+// At most depth documents are read, or all of them if depth is 0 or less. Items that were already read are skipped, and a document that links back to one that was read ends the walk
+func (f *Fetcher) followPages(ctx context.Context, feed *rss.Feed, depth int) (int, error) {
+	known := make(map[string]struct{})
+	for _, item := range feed.Items {
+		known[item.GUID] = struct{}{}
+	}
+	visited := make(map[string]bool)
+	if feed.FetchFrom != nil {
+		visited[feed.FetchFrom.String()] = true
+	}
+
+	n := 0
+	for page := feed; depth <= 0 || n < depth; n++ {
+		older := page.Paging.Older()
+		if older == nil || visited[older.String()] {
+			break
+		}
+		visited[older.String()] = true
+
+		r, contentType, err := f.fetchPage(ctx, older)
+		if err != nil {
+			return n, err
+		}
+		page, err = f.parse(r, contentType, older, nil)
+		r.Close()
+		if err != nil {
+			return n, err
+		}
+
+		for _, item := range page.Items {
+			if _, ok := known[item.GUID]; ok {
+				continue
+			}
+			known[item.GUID] = struct{}{}
+			item.Feed = feed
+			feed.Items = append(feed.Items, item)
+		}
+	}
+	return n, nil
+}
+
+// Backfill fetches the feed at rawurl and walks its archive (RFC 5005), reading up to depth older documents, or the whole archive if depth is 0 or less.
+// The returned Feed has the items of every document, newest first, and can be stored with database.BackfillItems.
+// If reading an older document fails, the feed is returned with the items read so far, along with the error
+func (f *Fetcher) Backfill(ctx context.Context, rawurl string, depth int) (*rss.Feed, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	r, contentType, err := f.fetchPage(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	feed, err := f.parse(r, contentType, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	feed.FetchFrom = u
+
+	if _, err := f.followPages(ctx, feed, depth); err != nil {
+		return feed, fmt.Errorf("failed to backfill feed %q: %w", rawurl, err)
+	}
+	return feed, nil
+}
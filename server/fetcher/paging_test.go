@@ -0,0 +1,192 @@
+package fetcher_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/server/fetcher"
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// archiveDocument returns an RSS document of an archived feed with the items guids, linking to the archive document prev unless it is ""
+func archiveDocument(prev string, guids ...int) string {
+	var sb strings.Builder
+	sb.WriteString(`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Archived</title><link>https://example.com/</link><description>Test</description>`)
+	if prev != "" {
+		fmt.Fprintf(&sb, `<atom:link rel="prev-archive" href="%s"/>`, prev)
+	}
+	for _, guid := range guids {
+		fmt.Fprintf(&sb, `<item><title>Item %[1]d</title><guid>%[1]d</guid></item>`, guid)
+	}
+	sb.WriteString(`</channel></rss>`)
+	return sb.String()
+}
+
+// archiveServer serves a feed with the items 5 and 4, and an archive of 4 and 3, then 2 and 1, which links back to the feed
+// It counts the requests for the archive documents in archived
+func archiveServer(archived *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			fmt.Fprint(w, archiveDocument("archive/2", 5, 4))
+		case "/archive/2":
+			archived.Add(1)
+			fmt.Fprint(w, archiveDocument("1", 4, 3))
+		case "/archive/1":
+			archived.Add(1)
+			fmt.Fprint(w, archiveDocument("../feed", 2, 1))
+		case "/broken":
+			fmt.Fprint(w, archiveDocument("missing", 6))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// titles returns the titles of the items of feed
+func titles(feed *rss.Feed) string {
+	var titles []string
+	for _, item := range feed.Items {
+		titles = append(titles, item.Title)
+	}
+	return strings.Join(titles, ", ")
+}
+
+func TestPagingOnFirstFetch(t *testing.T) {
+	t.Parallel()
+
+	var archived atomic.Int32
+	srv := archiveServer(&archived)
+	defer srv.Close()
+
+	ttlVar := ttl
+	f := fetcher.NewFetcher()
+	f.AddNewFeed(srv.URL+"/feed", &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	for _, expected := range []string{
+		"Item 5, Item 4, Item 3, Item 2, Item 1",
+		// later fetches only read the feed
+		"",
+	} {
+		select {
+		case feed := <-f.Ch.FetchedFeeds:
+			if got := titles(feed); got != expected {
+				t.Fatalf("expected items %q, got %q", expected, got)
+			}
+			for _, item := range feed.Items {
+				if item.Feed != feed {
+					t.Fatalf("expected %q to belong to the feed", item.Title)
+				}
+			}
+		case err := <-f.Ch.Err:
+			t.Fatalf("%s", err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out (no response after 3s)")
+		}
+	}
+
+	if n := archived.Load(); n != 2 {
+		t.Fatalf("expected the archive to be read once, got %d requests", n)
+	}
+}
+
+func TestNoPagingOfStoredFeeds(t *testing.T) {
+	t.Parallel()
+
+	var archived atomic.Int32
+	srv := archiveServer(&archived)
+	defer srv.Close()
+
+	ttlVar := 1 * time.Hour
+	f := fetcher.NewFetcher()
+	f.AddFeed(srv.URL+"/feed", &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	select {
+	case feed := <-f.Ch.FetchedFeeds:
+		if got := titles(feed); got != "Item 5, Item 4" {
+			t.Fatalf("expected only the newest document, got %q", got)
+		}
+	case err := <-f.Ch.Err:
+		t.Fatalf("%s", err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (no response after 3s)")
+	}
+
+	if n := archived.Load(); n != 0 {
+		t.Fatalf("expected the archive not to be read, got %d requests", n)
+	}
+}
+
+func TestPagingDepth(t *testing.T) {
+	t.Parallel()
+
+	var archived atomic.Int32
+	srv := archiveServer(&archived)
+	defer srv.Close()
+
+	ttlVar := 1 * time.Hour
+	f := fetcher.NewFetcher()
+	f.PagingDepth = 1
+	f.AddNewFeed(srv.URL+"/feed", &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	select {
+	case feed := <-f.Ch.FetchedFeeds:
+		if got := titles(feed); got != "Item 5, Item 4, Item 3" {
+			t.Fatalf("expected one archive document, got %q", got)
+		}
+	case err := <-f.Ch.Err:
+		t.Fatalf("%s", err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (no response after 3s)")
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	t.Parallel()
+
+	var archived atomic.Int32
+	srv := archiveServer(&archived)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	f := fetcher.NewFetcher()
+	feed, err := f.Backfill(ctx, srv.URL+"/feed", 0)
+	if err != nil {
+		t.Fatalf("Backfill: %s", err)
+	}
+	if got := titles(feed); got != "Item 5, Item 4, Item 3, Item 2, Item 1" {
+		t.Fatalf("expected the whole archive, got %q", got)
+	}
+
+	// the walk ends where the documents link back to one that was read
+	feed, err = f.Backfill(ctx, srv.URL+"/archive/2", 0)
+	if err != nil || titles(feed) != "Item 4, Item 3, Item 2, Item 1, Item 5" {
+		t.Fatalf("unexpected items %q (%v)", titles(feed), err)
+	}
+
+	// a broken link keeps what was read
+	feed, err = f.Backfill(ctx, srv.URL+"/broken", 0)
+	if err == nil || feed == nil || titles(feed) != "Item 6" {
+		t.Fatalf("expected an error and the first document, got %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package fetcher
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/server/httpserver"
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// The path of the notification endpoint on the httpserver.Server, under /content/
+const rssCloudPath = "rsscloud"
+
+// Clouds forget registrations after 25 hours, so they are renewed a little earlier
+const rssCloudRenewal = 24 * time.Hour
+
+/*
+RSSCloud registers the feeds of a Fetcher with their <cloud> (https://www.rssboard.org/rsscloud-interface), and refetches a feed as soon as the cloud notifies that it changed.
+Notifications of every feed are received at /content/rsscloud on the httpserver.Server. Only the http-post protocol is supported. Registered feeds are still polled.
+*/
+type RSSCloud struct {
+	// The public URL of the /content/ route of the httpserver.Server, which the notification path is appended to
+	callbackBase *url.URL
+	ch           *httpserver.HttpServerChannels
+	fetcher      *Fetcher
+
+	mu sync.Mutex
+	// whether the notification endpoint was added to the httpserver.Server
+	serving bool
+	// the registered feeds, by the URL they are fetched from
+	feeds map[string]*cloudFeed
+}
+
+type cloudFeed struct {
+	ff    *FetchFeed
+	cloud *rss.Cloud
+	// registers the feed again before the cloud forgets it
+	renew *time.Timer
+}
+
+// notifyResult is the response of a cloud to a registration
+type notifyResult struct {
+	Success bool   `xml:"success,attr"`
+	Msg     string `xml:"msg,attr"`
+}
+
+// EnableRSSCloud sets the RSSCloud of f, which registers feeds that have a <cloud> when they are fetched.
+// callbackBase is the URL the /content/ route of the httpserver.Server is reachable at from the clouds, like "http://fedup.example.com:4545/content/".
+// The notification endpoint is added through ch
+func (f *Fetcher) EnableRSSCloud(callbackBase string, ch *httpserver.HttpServerChannels) (*RSSCloud, error) {
+	base, err := parseCallbackBase(callbackBase, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	f.RSSCloud = &RSSCloud{callbackBase: base, ch: ch, fetcher: f, feeds: make(map[string]*cloudFeed)}
+	return f.RSSCloud, nil
+}
+
+// registerFeed registers ff with cloud, unless it is registered already. Errors are sent to Err
+func (rc *RSSCloud) registerFeed(ff *FetchFeed, cloud *rss.Cloud) {
+	if !strings.EqualFold(cloud.Protocol, "http-post") {
+		log.Printf("rssCloud protocol %q of feed %q is not supported (non-fatal)", cloud.Protocol, ff.url.String())
+		return
+	}
+
+	rc.mu.Lock()
+	if _, ok := rc.feeds[ff.url.String()]; ok {
+		rc.mu.Unlock()
+		return
+	}
+	cf := &cloudFeed{ff: ff, cloud: cloud}
+	rc.feeds[ff.url.String()] = cf
+	serving := rc.serving
+	rc.serving = true
+	rc.mu.Unlock()
+
+	if !serving {
+		rc.ch.ServeContent <- httpserver.Content{
+			Path:        rssCloudPath,
+			Handler:     rc.handleNotify,
+			ContentType: "text/plain; charset=utf-8",
+		}
+	}
+
+	go func() {
+		if err := rc.register(cf); err != nil {
+			rc.forget(cf)
+			rc.fetcher.Ch.Err <- err
+		}
+	}()
+}
+
+// register asks the cloud of cf to notify the endpoint when the feed changes, and renews the registration before it expires.
+// The cloud verifies the endpoint with a challenge before it responds
+func (rc *RSSCloud) register(cf *cloudFeed) error {
+	port := rc.callbackBase.Port()
+	if port == "" {
+		port = "80"
+		if rc.callbackBase.Scheme == "https" {
+			port = "443"
+		}
+	}
+	form := url.Values{
+		"notifyProcedure": {""},
+		"domain":          {rc.callbackBase.Hostname()},
+		"port":            {port},
+		"path":            {rc.callbackBase.JoinPath(rssCloudPath).Path},
+		"protocol":        {"http-post"},
+		"url1":            {cf.ff.url.String()},
+	}
+
+	// clouds are plain HTTP, unless they are on the HTTPS port
+	scheme, cloudPort := "http", cf.cloud.Port
+	switch cloudPort {
+	case 0:
+		cloudPort = 80
+	case 443:
+		scheme = "https"
+	}
+	endpoint := &url.URL{Scheme: scheme, Host: net.JoinHostPort(cf.cloud.Domain, strconv.Itoa(cloudPort)), Path: cf.cloud.Path}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w on cloud %q", err, endpoint.String())
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", userAgent)
+
+	if rc.fetcher.client == nil {
+		return errors.New("fetcher.client is nil")
+	}
+
+	resp, err := rc.fetcher.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to register feed %q with cloud %q: %w", cf.ff.url.String(), endpoint.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got unhappy status code from cloud %q for feed %q: %s", endpoint.String(), cf.ff.url.String(), resp.Status)
+	}
+
+	var result notifyResult
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&result); err != nil {
+		return fmt.Errorf("failed to parse response of cloud %q: %w", endpoint.String(), err)
+	}
+	if !result.Success {
+		return fmt.Errorf("cloud %q refused to register feed %q: %s", endpoint.String(), cf.ff.url.String(), result.Msg)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.feeds[cf.ff.url.String()] == cf {
+		cf.renew = time.AfterFunc(rssCloudRenewal, func() {
+			if err := rc.register(cf); err != nil {
+				// the feed is registered again on its next fetch
+				rc.forget(cf)
+				go func(e error) { rc.fetcher.Ch.Err <- e }(fmt.Errorf("failed to renew registration: %w", err))
+			}
+		})
+	}
+	return nil
+}
+
+// forget removes the registration cf, unless the feed was registered again since
+func (rc *RSSCloud) forget(cf *cloudFeed) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.feeds[cf.ff.url.String()] == cf {
+		delete(rc.feeds, cf.ff.url.String())
+	}
+}
+
+// handleNotify is the httpserver.ContentHandler of the notification endpoint.
+// GET requests are the challenge of a cloud verifying a registration, POST requests notify that the feed with the url parameter changed
+func (rc *RSSCloud) handleNotify(path string, contentType string, w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	rc.mu.Lock()
+	cf, ok := rc.feeds[r.Form.Get("url")]
+	rc.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	switch r.Method {
+	case http.MethodGet:
+		fmt.Fprint(w, r.Form.Get("challenge"))
+	case http.MethodPost:
+		cf.ff.refetch()
+		fmt.Fprint(w, "ok")
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Close stops renewing the registrations and removes the notification endpoint. The clouds keep them until they expire
+func (rc *RSSCloud) Close() {
+	rc.mu.Lock()
+	for u, cf := range rc.feeds {
+		if cf.renew != nil {
+			cf.renew.Stop()
+		}
+		delete(rc.feeds, u)
+	}
+	serving := rc.serving
+	rc.serving = false
+	rc.mu.Unlock()
+
+	if serving {
+		rc.ch.RemoveContent <- rssCloudPath
+	}
+}
@@ -0,0 +1,139 @@
+package fetcher_test
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/server/fetcher"
+)
+
+// newStubCloud returns an rssCloud that verifies every http-post registration with a challenge, and sends the registrations to registered
+func newStubCloud(t *testing.T, registered chan<- url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.URL.Path != "/pleaseNotify" || r.PostForm.Get("protocol") != "http-post" {
+			fmt.Fprint(w, `<notifyResult success="false" msg="Bad registration."/>`)
+			return
+		}
+
+		endpoint := &url.URL{
+			Scheme:   "http",
+			Host:     net.JoinHostPort(r.PostForm.Get("domain"), r.PostForm.Get("port")),
+			Path:     r.PostForm.Get("path"),
+			RawQuery: url.Values{"url": {r.PostForm.Get("url1")}, "challenge": {"5ac8e6"}}.Encode(),
+		}
+		resp, err := http.Get(endpoint.String())
+		if err != nil {
+			t.Errorf("failed to verify endpoint: %s", err)
+			return
+		}
+		defer resp.Body.Close()
+		if body, _ := io.ReadAll(resp.Body); string(body) != "5ac8e6" {
+			fmt.Fprint(w, `<notifyResult success="false" msg="The challenge was not echoed."/>`)
+			return
+		}
+
+		fmt.Fprint(w, `<notifyResult success="true" msg="Thanks for the registration."/>`)
+		endpoint.RawQuery = ""
+		r.PostForm.Set("endpoint", endpoint.String())
+		registered <- r.PostForm
+	}))
+}
+
+func TestRSSCloud(t *testing.T) {
+	registered := make(chan url.Values, 2)
+	cloud := newStubCloud(t, registered)
+	defer cloud.Close()
+	cloudURL, _ := url.Parse(cloud.URL)
+
+	var fetches atomic.Int32
+	feedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := fetches.Add(1)
+		fmt.Fprintf(w, `<rss version="2.0"><channel><title>Clouded</title><link>https://example.com/</link><description>Test</description>`+
+			`<cloud domain="%s" port="%s" path="/pleaseNotify" registerProcedure="" protocol="http-post"/><item><guid>%d</guid></item></channel></rss>`,
+			cloudURL.Hostname(), cloudURL.Port(), n)
+	}))
+	defer feedSrv.Close()
+
+	ttlVar := 1 * time.Hour
+	f := fetcher.NewFetcher()
+	rc, err := f.EnableRSSCloud(fmt.Sprintf("http://127.0.0.1:%d/content/", callbacksPort), callbacksCh)
+	if err != nil {
+		t.Fatalf("EnableRSSCloud: %s", err)
+	}
+	defer rc.Close()
+	f.AddFeed(feedSrv.URL+"/feed", &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	next := func() string {
+		t.Helper()
+		select {
+		case feed := <-f.Ch.FetchedFeeds:
+			if len(feed.Items) != 1 {
+				t.Fatalf("expected one item, got %d", len(feed.Items))
+			}
+			return feed.Items[0].GUID
+		case err := <-f.Ch.Err:
+			t.Fatalf("%s", err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out (no response after 3s)")
+		}
+		return ""
+	}
+
+	if guid := next(); guid != "1" {
+		t.Fatalf("expected the first fetch, got item %s", guid)
+	}
+
+	var form url.Values
+	select {
+	case form = <-registered:
+	case err := <-f.Ch.Err:
+		t.Fatalf("%s", err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (the cloud got no registration after 3s)")
+	}
+	if form.Get("url1") != feedSrv.URL+"/feed" || !strings.HasSuffix(form.Get("endpoint"), "/content/rsscloud") {
+		t.Fatalf("expected the feed to be registered with the endpoint, got %v", form)
+	}
+
+	// notifications of other feeds are refused
+	resp, err := http.PostForm(form.Get("endpoint"), url.Values{"url": {"https://example.com/other.xml"}})
+	if err != nil {
+		t.Fatalf("failed to notify: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected an unknown feed to be refused, got %s", resp.Status)
+	}
+
+	// a notification fetches the feed long before the ttl
+	resp, err = http.PostForm(form.Get("endpoint"), url.Values{"url": {form.Get("url1")}})
+	if err != nil {
+		t.Fatalf("failed to notify: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the notification to be accepted, got %s", resp.Status)
+	}
+	if guid := next(); guid != "2" {
+		t.Fatalf("expected a refetch, got item %s", guid)
+	}
+
+	// the feed is registered once
+	select {
+	case form := <-registered:
+		t.Fatalf("unexpected registration %v", form)
+	default:
+	}
+}
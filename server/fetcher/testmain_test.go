@@ -0,0 +1,28 @@
+package fetcher_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/server/httpserver"
+)
+
+// the server the WebSub and rssCloud callbacks are served on. RunServer registers its routes on http.DefaultServeMux, so it only runs once
+var (
+	callbacks   *httpserver.Server
+	callbacksCh *httpserver.HttpServerChannels
+)
+
+// the port of callbacks, different from the one of the httpserver tests
+const callbacksPort = 55929
+
+func TestMain(m *testing.M) {
+	callbacksCh = &httpserver.HttpServerChannels{Err: make(chan error, 1), ServeContent: make(chan httpserver.Content), RemoveContent: make(chan string)}
+	callbacks = httpserver.RunServer(callbacksPort, callbacksCh)
+
+	code := m.Run()
+
+	callbacks.Shutdown(context.Background())
+	os.Exit(code)
+}
@@ -0,0 +1,336 @@
+package fetcher
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/server/httpserver"
+)
+
+// The largest content notification that will be read. Hubs send the whole feed, so this is as generous as the attachment limit
+const maxNotificationSize = 10 << 20
+
+// The prefix of the paths of the callbacks on the httpserver.Server, under /content/
+const webSubPrefix = "websub/"
+
+// The lease that is asked for when subscribing, the hub may grant a different one
+const defaultLeaseSeconds = 10 * 24 * 60 * 60
+
+/*
+WebSub subscribes the feeds of a Fetcher to their hubs (https://www.w3.org/TR/websub/), so new items arrive as soon as they are published.
+Each subscription has a callback at /content/websub/<id> on the httpserver.Server, which answers the intent verification of the hub
+and reads content notifications, whose items are sent to FetchedFeeds like those of a fetch. Subscribed feeds are still polled.
+*/
+type WebSub struct {
+	// The public URL of the /content/ route of the httpserver.Server, which the callbacks are appended to
+	callbackBase *url.URL
+	ch           *httpserver.HttpServerChannels
+	fetcher      *Fetcher
+	// The lease in seconds that is asked for when subscribing. Subscriptions are renewed before their lease expires
+	LeaseSeconds int
+
+	mu   sync.Mutex
+	subs map[string]*subscription
+}
+
+type subscription struct {
+	id     string
+	ff     *FetchFeed
+	hub    *url.URL
+	topic  *url.URL
+	secret string
+	// renews the subscription before the lease granted by the hub expires
+	renew *time.Timer
+}
+
+// EnableWebSub sets the WebSub of f, which subscribes feeds that have a hub when they are fetched.
+// callbackBase is the URL the /content/ route of the httpserver.Server is reachable at from the hubs, like "https://fedup.example.com/content/".
+// Callbacks are added and removed through ch
+func (f *Fetcher) EnableWebSub(callbackBase string, ch *httpserver.HttpServerChannels) (*WebSub, error) {
+	base, err := parseCallbackBase(callbackBase, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	f.WebSub = &WebSub{callbackBase: base, ch: ch, fetcher: f, LeaseSeconds: defaultLeaseSeconds, subs: make(map[string]*subscription)}
+	return f.WebSub, nil
+}
+
+// parseCallbackBase parses the public URL of the /content/ route of the httpserver.Server, and checks that content can be added and removed through ch
+func parseCallbackBase(callbackBase string, ch *httpserver.HttpServerChannels) (*url.URL, error) {
+	base, err := url.Parse(callbackBase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse callback URL: %w", err)
+	}
+	if !base.IsAbs() {
+		return nil, fmt.Errorf("callback URL %q is not absolute", callbackBase)
+	}
+	if ch == nil || ch.ServeContent == nil || ch.RemoveContent == nil {
+		return nil, errors.New("ServeContent and RemoveContent must not be nil")
+	}
+	return base, nil
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	// never returns an error
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// subscribeFeed subscribes ff to hub under topic, unless it already has a subscription. Errors are sent to Err
+func (ws *WebSub) subscribeFeed(ff *FetchFeed, hub, topic *url.URL) {
+	ws.mu.Lock()
+	for _, s := range ws.subs {
+		if s.ff == ff {
+			ws.mu.Unlock()
+			return
+		}
+	}
+	s := &subscription{id: randomHex(16), ff: ff, hub: hub, topic: topic, secret: randomHex(32)}
+	ws.subs[s.id] = s
+	ws.mu.Unlock()
+
+	ws.ch.ServeContent <- httpserver.Content{
+		Path:        webSubPrefix + s.id,
+		Handler:     ws.handleCallback,
+		ContentType: "text/plain; charset=utf-8",
+	}
+
+	go func() {
+		if err := ws.subscribe(s); err != nil {
+			ws.remove(s.id)
+			ws.fetcher.Ch.Err <- err
+		}
+	}()
+}
+
+// subscribe sends a subscription request for s to its hub. The hub verifies the intent later, at the callback
+func (ws *WebSub) subscribe(s *subscription) error {
+	form := url.Values{
+		"hub.mode":     {"subscribe"},
+		"hub.topic":    {s.topic.String()},
+		"hub.callback": {ws.callbackBase.JoinPath(webSubPrefix + s.id).String()},
+		"hub.secret":   {s.secret},
+	}
+	if ws.LeaseSeconds > 0 {
+		form.Set("hub.lease_seconds", strconv.Itoa(ws.LeaseSeconds))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.hub.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w on hub %q", err, s.hub.String())
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", userAgent)
+
+	if ws.fetcher.client == nil {
+		return errors.New("fetcher.client is nil")
+	}
+
+	resp, err := ws.fetcher.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %q on hub %q: %w", s.topic.String(), s.hub.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("got unhappy status code from hub %q for %q: %s %s", s.hub.String(), s.topic.String(), resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// remove forgets the subscription with id and its callback
+func (ws *WebSub) remove(id string) {
+	ws.mu.Lock()
+	s, ok := ws.subs[id]
+	if ok {
+		delete(ws.subs, id)
+		if s.renew != nil {
+			s.renew.Stop()
+		}
+	}
+	ws.mu.Unlock()
+
+	if ok {
+		ws.ch.RemoveContent <- webSubPrefix + id
+	}
+}
+
+// handleCallback is the httpserver.ContentHandler of the callbacks. GET requests verify intent, POST requests notify of new content
+func (ws *WebSub) handleCallback(path string, contentType string, w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(path, webSubPrefix)
+	ws.mu.Lock()
+	s, ok := ws.subs[id]
+	ws.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	switch r.Method {
+	case http.MethodGet:
+		ws.verify(s, w, r)
+	case http.MethodPost:
+		ws.notify(s, w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify answers the verification of intent (section 5.3) and denials (section 5.2) of the hub
+func (ws *WebSub) verify(s *subscription, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("hub.topic") != s.topic.String() {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch q.Get("hub.mode") {
+	case "subscribe":
+		challenge := q.Get("hub.challenge")
+		if challenge == "" {
+			http.Error(w, "missing hub.challenge", http.StatusBadRequest)
+			return
+		}
+
+		lease, err := strconv.Atoi(q.Get("hub.lease_seconds"))
+		if err != nil {
+			log.Printf("hub %q sent invalid lease %q for %q (non-fatal)", s.hub.String(), q.Get("hub.lease_seconds"), s.topic.String())
+			lease = 0
+		}
+		ws.lease(s, time.Duration(lease)*time.Second)
+		fmt.Fprint(w, challenge)
+	case "denied":
+		log.Printf("hub %q denied the subscription to %q: %s", s.hub.String(), s.topic.String(), q.Get("hub.reason"))
+		// the callback is still being requested, so it is removed after responding
+		go ws.remove(s.id)
+	default:
+		// unsubscribing is never requested, so there is no intent to verify
+		http.NotFound(w, r)
+	}
+}
+
+// lease renews s when 90% of the lease d granted by the hub has passed. A lease of 0 is not renewed
+func (ws *WebSub) lease(s *subscription, d time.Duration) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if s.renew != nil {
+		s.renew.Stop()
+		s.renew = nil
+	}
+	if d <= 0 {
+		return
+	}
+
+	s.renew = time.AfterFunc(d*9/10, func() {
+		if err := ws.subscribe(s); err != nil {
+			// the feed is subscribed again on its next fetch
+			ws.remove(s.id)
+			go func(e error) { ws.fetcher.Ch.Err <- e }(fmt.Errorf("failed to renew subscription: %w", err))
+		}
+	})
+}
+
+// signatureHash returns the hash function of a method in the X-Hub-Signature header, or nil if it is not supported
+func signatureHash(method string) func() hash.Hash {
+	switch method {
+	case "sha1":
+		return sha1.New
+	case "sha256":
+		return sha256.New
+	case "sha384":
+		return sha512.New384
+	case "sha512":
+		return sha512.New
+	}
+	return nil
+}
+
+// validSignature reports whether header, an X-Hub-Signature value like "sha256=<hex>", is the HMAC of body with secret
+func validSignature(header, secret string, body []byte) bool {
+	method, sig, ok := strings.Cut(header, "=")
+	if !ok {
+		return false
+	}
+	h := signatureHash(method)
+	if h == nil {
+		return false
+	}
+	expected, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// notify reads a content distribution request (section 7) of the hub. Notifications without a valid signature are acknowledged, but ignored
+func (ws *WebSub) notify(s *subscription, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxNotificationSize+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxNotificationSize {
+		http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// the hub must not learn whether the signature was valid (section 8)
+	w.WriteHeader(http.StatusAccepted)
+	if !validSignature(r.Header.Get("X-Hub-Signature"), s.secret, body) {
+		log.Printf("ignoring notification for %q with an invalid signature (non-fatal)", s.topic.String())
+		return
+	}
+
+	ff := s.ff
+	parsed, err := ws.fetcher.parse(bytes.NewReader(body), r.Header.Get("Content-Type"), s.topic, ff.knownGUIDs())
+	if err != nil {
+		go func(e error) { ws.fetcher.Ch.Err <- e }(fmt.Errorf("failed to read notification from hub %q: %w", s.hub.String(), err))
+		return
+	}
+	parsed.FetchFrom = ff.url
+
+	// a fetch may have delivered some of the items since the snapshot was taken
+	ff.mu.Lock()
+	parsed.Items = ff.remember(parsed.Items)
+	ff.mu.Unlock()
+	ws.fetcher.Ch.FetchedFeeds <- parsed
+}
+
+// Close stops renewing the subscriptions and removes their callbacks. The hubs keep them until their leases expire
+func (ws *WebSub) Close() {
+	ws.mu.Lock()
+	ids := make([]string, 0, len(ws.subs))
+	for id := range ws.subs {
+		ids = append(ids, id)
+	}
+	ws.mu.Unlock()
+
+	for _, id := range ids {
+		ws.remove(id)
+	}
+}
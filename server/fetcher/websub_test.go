@@ -0,0 +1,305 @@
+package fetcher_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/server/fetcher"
+)
+
+// stubHub is a WebSub hub that verifies every subscription with a lease of one second
+type stubHub struct {
+	*httptest.Server
+	t *testing.T
+	// the subscription requests the hub received, after their intent was verified
+	verified chan url.Values
+	// the numbers of the subscription requests, counting from 1, that the hub refuses. Set before the first request
+	refuse   map[int32]bool
+	requests atomic.Int32
+}
+
+func newStubHub(t *testing.T) *stubHub {
+	h := &stubHub{t: t, verified: make(chan url.Values, 4)}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("hub.mode") != "subscribe" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if h.refuse[h.requests.Add(1)] {
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		go h.verify(r.PostForm)
+	}))
+	return h
+}
+
+// verify requests the callback of the subscription form with a challenge, like a hub verifying the intent of the subscriber
+func (h *stubHub) verify(form url.Values) {
+	callback, _ := url.Parse(form.Get("hub.callback"))
+	callback.RawQuery = url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {form.Get("hub.topic")},
+		"hub.challenge":     {"c4a11e9e"},
+		"hub.lease_seconds": {"1"},
+	}.Encode()
+
+	resp, err := http.Get(callback.String())
+	if err != nil {
+		h.t.Errorf("failed to verify intent: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "c4a11e9e" {
+		h.t.Errorf("expected the challenge to be echoed, got %s %q", resp.Status, body)
+		return
+	}
+	h.verified <- form
+}
+
+// publish sends doc to the callback of the subscription form, signed with secret
+func (h *stubHub) publish(form url.Values, doc, secret string) {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(doc))
+
+	req, _ := http.NewRequest(http.MethodPost, form.Get("hub.callback"), strings.NewReader(doc))
+	req.Header.Set("Content-Type", "application/rss+xml")
+	req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		h.t.Fatalf("failed to publish: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		h.t.Fatalf("expected the notification to be acknowledged, got %s", resp.Status)
+	}
+}
+
+// pushedDocument returns an RSS document with the items guids that links to hub and its own URL self
+func pushedDocument(hub, self string, guids ...int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Pushed</title><link>https://example.com/</link><description>Test</description>`)
+	fmt.Fprintf(&sb, `<atom:link rel="hub" href="%s"/><atom:link rel="self" href="%s"/>`, hub, self)
+	for _, guid := range guids {
+		fmt.Fprintf(&sb, `<item><title>Item %[1]d</title><guid>%[1]d</guid></item>`, guid)
+	}
+	sb.WriteString(`</channel></rss>`)
+	return sb.String()
+}
+
+func TestWebSub(t *testing.T) {
+	hub := newStubHub(t)
+	defer hub.Close()
+
+	var self string
+	feedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pushedDocument(hub.URL, self, 1))
+	}))
+	defer feedSrv.Close()
+	self = feedSrv.URL + "/self"
+
+	ttlVar := 1 * time.Hour
+	f := fetcher.NewFetcher()
+	ws, err := f.EnableWebSub(fmt.Sprintf("http://127.0.0.1:%d/content/", callbacksPort), callbacksCh)
+	if err != nil {
+		t.Fatalf("EnableWebSub: %s", err)
+	}
+	defer ws.Close()
+	f.AddFeed(feedSrv.URL+"/feed", &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	next := func() []string {
+		t.Helper()
+		select {
+		case feed := <-f.Ch.FetchedFeeds:
+			var guids []string
+			for _, item := range feed.Items {
+				guids = append(guids, item.GUID)
+			}
+			return guids
+		case err := <-f.Ch.Err:
+			t.Fatalf("%s", err)
+		case err := <-callbacksCh.Err:
+			t.Fatalf("%s", err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out (no response after 3s)")
+		}
+		return nil
+	}
+	verified := func() url.Values {
+		t.Helper()
+		select {
+		case form := <-hub.verified:
+			return form
+		case err := <-f.Ch.Err:
+			t.Fatalf("%s", err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out (the hub verified no subscription after 3s)")
+		}
+		return nil
+	}
+
+	if got := next(); len(got) != 1 || got[0] != "1" {
+		t.Fatalf("expected the first fetch to have item 1, got %v", got)
+	}
+
+	form := verified()
+	if form.Get("hub.topic") != self || form.Get("hub.secret") == "" {
+		t.Fatalf("expected a subscription to the self link with a secret, got %v", form)
+	}
+
+	// a forged notification is acknowledged, but its items never arrive. Item 1 is known already
+	hub.publish(form, pushedDocument(hub.URL, self, 666, 1), "not the secret")
+	hub.publish(form, pushedDocument(hub.URL, self, 2, 1), form.Get("hub.secret"))
+	if got := next(); len(got) != 1 || got[0] != "2" {
+		t.Fatalf("expected the notification to have item 2, got %v", got)
+	}
+
+	// the lease of one second is renewed with the same callback
+	if renewed := verified(); renewed.Get("hub.callback") != form.Get("hub.callback") {
+		t.Fatalf("expected the subscription to be renewed, got %v", renewed)
+	}
+}
+
+func TestWebSubRenewalFails(t *testing.T) {
+	hub := newStubHub(t)
+	defer hub.Close()
+	// the renewal of the first subscription is refused
+	hub.refuse = map[int32]bool{2: true}
+
+	var self string
+	feedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pushedDocument(hub.URL, self, 1))
+	}))
+	defer feedSrv.Close()
+	self = feedSrv.URL + "/self"
+
+	ttlVar := ttl
+	f := fetcher.NewFetcher()
+	ws, err := f.EnableWebSub(fmt.Sprintf("http://127.0.0.1:%d/content/", callbacksPort), callbacksCh)
+	if err != nil {
+		t.Fatalf("EnableWebSub: %s", err)
+	}
+	defer ws.Close()
+	f.AddFeed(feedSrv.URL+"/feed", &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	var first url.Values
+	renewFailed := false
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-f.Ch.FetchedFeeds:
+		case err := <-f.Ch.Err:
+			if first == nil || renewFailed || !strings.Contains(err.Error(), "failed to renew") {
+				t.Fatalf("%s", err)
+			}
+			renewFailed = true
+		case form := <-hub.verified:
+			switch {
+			case first == nil:
+				first = form
+			case !renewFailed:
+				t.Fatalf("expected the renewal to fail, got subscription %v", form)
+			case form.Get("hub.callback") == first.Get("hub.callback"):
+				t.Fatalf("expected the feed to be subscribed with a new callback, got %v", form)
+			default:
+				// a later fetch subscribed the feed again
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out (renewal failed: %t)", renewFailed)
+		}
+	}
+}
+
+func TestWebSubDuringSlowFetch(t *testing.T) {
+	hub := newStubHub(t)
+	defer hub.Close()
+
+	var self string
+	var fetches atomic.Int32
+	stalled, release := make(chan struct{}), make(chan struct{})
+	feedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) != 2 {
+			fmt.Fprint(w, pushedDocument(hub.URL, self, 1))
+			return
+		}
+		// the second fetch sends half of the document, then stalls
+		doc := pushedDocument(hub.URL, self, 1)
+		fmt.Fprint(w, doc[:len(doc)/2])
+		w.(http.Flusher).Flush()
+		close(stalled)
+		<-release
+	}))
+	defer feedSrv.Close()
+	defer close(release)
+	self = feedSrv.URL + "/self"
+
+	ttlVar := ttl
+	f := fetcher.NewFetcher()
+	ws, err := f.EnableWebSub(fmt.Sprintf("http://127.0.0.1:%d/content/", callbacksPort), callbacksCh)
+	if err != nil {
+		t.Fatalf("EnableWebSub: %s", err)
+	}
+	defer ws.Close()
+	f.AddFeed(feedSrv.URL+"/feed", &ttlVar)
+	if err := f.Start(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defer func() { _ = f.Stop() }()
+
+	var form url.Values
+	for form == nil {
+		select {
+		case <-f.Ch.FetchedFeeds:
+		case form = <-hub.verified:
+		case err := <-f.Ch.Err:
+			t.Fatalf("%s", err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out (the hub verified no subscription after 3s)")
+		}
+	}
+	select {
+	case <-stalled:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (no second fetch after 3s)")
+	}
+	// let the fetcher start parsing the half it got
+	time.Sleep(100 * time.Millisecond)
+
+	// the stalled fetch does not hold up notifications
+	start := time.Now()
+	hub.publish(form, pushedDocument(hub.URL, self, 2, 1), form.Get("hub.secret"))
+	if d := time.Since(start); d > 3*time.Second {
+		t.Fatalf("the notification waited %s for the stalled fetch", d)
+	}
+	select {
+	case feed := <-f.Ch.FetchedFeeds:
+		if len(feed.Items) != 1 || feed.Items[0].GUID != "2" {
+			t.Fatalf("expected the notification to have item 2, got %d items", len(feed.Items))
+		}
+	case err := <-f.Ch.Err:
+		t.Fatalf("%s", err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out (the notification did not arrive after 3s)")
+	}
+}
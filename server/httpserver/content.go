@@ -25,7 +25,9 @@ type Content struct {
 func (s *Server) handleContent(w http.ResponseWriter, r *http.Request) {
 	uri := r.URL.Path
 	path := strings.TrimPrefix(uri, "/content/")
+	s.contentMutex.RLock()
 	c, ok := s.Contents[path]
+	s.contentMutex.RUnlock()
 	if !ok {
 		w.WriteHeader(http.StatusNotFound)
 		return
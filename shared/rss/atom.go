@@ -0,0 +1,390 @@
+package rss
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// The namespace of Atom 1.0 documents. See RFC 4287
+const atomNS = "http://www.w3.org/2005/Atom"
+
+// atomText returns the contents of an Atom text construct. xhtml content is returned as its serialized markup
+func atomText(e *etree.Element) string {
+	if e == nil {
+		return ""
+	}
+
+	if e.SelectAttrValue("type", "text") != "xhtml" {
+		return strings.TrimSpace(e.Text())
+	}
+
+	// xhtml content is wrapped in a <div> that is not part of the content
+	div := e.SelectElement("div")
+	if div == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, c := range div.Child {
+		c.WriteTo(&sb, &etree.WriteSettings{})
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// atomLinks returns the first link with rel="alternate" (the default relation) and every link with rel="enclosure"
+func atomLinks(e *etree.Element) (alternate *etree.Element, enclosures []*etree.Element) {
+	for link := range childrenNS(e, atomNS, "link") {
+		switch link.SelectAttrValue("rel", "alternate") {
+		case "alternate":
+			if alternate == nil {
+				alternate = link
+			}
+		case "enclosure":
+			enclosures = append(enclosures, link)
+		}
+	}
+	return
+}
+
+// atomRelLink returns the first link with the relation rel under e, or nil if there is none
+// RSS 2.0 feeds have them in the Atom namespace too, like rel="self" with the URL the feed is fetched from
+func atomRelLink(e *etree.Element, rel string) *etree.Element {
+	for link := range childrenNS(e, atomNS, "link") {
+		if link.SelectAttrValue("rel", "") == rel {
+			return link
+		}
+	}
+	return nil
+}
+
+// atomFeedLinks sets the FetchFrom, Hub and Paging of feed from the links of the Atom <feed> or the RSS <channel> e
+func atomFeedLinks(e *etree.Element, feed *Feed, d *diagnostics) {
+	if self := atomRelLink(e, "self"); self != nil {
+		feed.FetchFrom = d.parseURL(elementPath(self), self.SelectAttrValue("href", ""))
+	}
+	if hub := atomRelLink(e, "hub"); hub != nil {
+		feed.Hub = d.parseURL(elementPath(hub), hub.SelectAttrValue("href", ""))
+	}
+	feed.Paging = atomPaging(e, d)
+}
+
+// atomCategories returns every <category> under e. The scheme of a category is kept as its Domain
+func atomCategories(e *etree.Element) []*Category {
+	var categories []*Category
+	for el := range childrenNS(e, atomNS, "category") {
+		if term := el.SelectAttrValue("term", ""); term != "" {
+			categories = append(categories, &Category{Term: term, Domain: el.SelectAttrValue("scheme", "")})
+		}
+	}
+	return categories
+}
+
+// atomAuthors returns the Atom person of every <author> of e
+func atomAuthors(e *etree.Element, d *diagnostics) []*Person {
+	var authors []*Person
+	for author := range childrenNS(e, atomNS, "author") {
+		p := &Person{
+			Name:  strings.TrimSpace(selectNS(author, atomNS, "name").NotNil().Text()),
+			Email: strings.TrimSpace(selectNS(author, atomNS, "email").NotNil().Text()),
+			URI:   atomURL(author, "uri", d),
+		}
+		if p.Name != "" || p.Email != "" || p.URI != nil {
+			authors = append(authors, p)
+		}
+	}
+	return authors
+}
+
+// atomURL parses the text of the first Atom element named tag under e as a URL, returning nil if there is none and warning if it is malformed
+func atomURL(e *etree.Element, tag string, d *diagnostics) *url.URL {
+	el := selectNS(e, atomNS, tag)
+	if el == nil {
+		return nil
+	}
+	return d.parseURL(elementPath(el), el.Text())
+}
+
+func feedElementToFeed(e *etree.Element, d *diagnostics) (*Feed, error) {
+	if e.Tag != "feed" {
+		return nil, errors.New("element is not a <feed>")
+	}
+
+	feed := &Feed{}
+
+	if title := selectNS(e, atomNS, "title"); title == nil {
+		return nil, errors.New("<feed> does not contain <title>")
+	} else {
+		feed.Title = atomText(title)
+	}
+
+	feed.Description = atomText(selectNS(e, atomNS, "subtitle"))
+
+	if link, _ := atomLinks(e); link != nil {
+		feed.Link = d.parseURL(elementPath(link), link.SelectAttrValue("href", ""))
+	}
+
+	atomFeedLinks(e, feed, d)
+
+	feed.Language = e.SelectAttrValue("xml:lang", "")
+	feed.Copyright = atomText(selectNS(e, atomNS, "rights"))
+	feed.Generator = strings.TrimSpace(selectNS(e, atomNS, "generator").NotNil().Text())
+	if updated := selectNS(e, atomNS, "updated"); updated != nil {
+		feed.LastBuildDate = d.parseDate(elementPath(updated), updated.Text())
+	}
+	feed.Categories = atomCategories(e)
+
+	// the logo is larger than the icon, which is meant for favicons
+	logo := atomURL(e, "logo", d)
+	if logo == nil {
+		logo = atomURL(e, "icon", d)
+	}
+	if logo != nil {
+		feed.Image = &Image{URL: logo, Title: feed.Title, Link: feed.Link}
+	}
+
+	return feed, nil
+}
+
+func entryElementToItem(e *etree.Element, feed *Feed, feedAuthors []*Person, d *diagnostics) (*Item, error) {
+	if e.Tag != "entry" {
+		return nil, errors.New("element is not an <entry>")
+	}
+
+	item := &Item{
+		Feed:  feed,
+		GUID:  strings.TrimSpace(selectNS(e, atomNS, "id").NotNil().Text()),
+		Title: atomText(selectNS(e, atomNS, "title")),
+	}
+
+	// out-of-line content (with a src attribute) has nothing to show
+	if content := selectNS(e, atomNS, "content"); content != nil && content.SelectAttr("src") == nil {
+		item.Content = atomText(content)
+	}
+
+	// prefer the summary, falling back to the content
+	if summary := selectNS(e, atomNS, "summary"); summary != nil {
+		item.Description = atomText(summary)
+	} else {
+		item.Description = item.Content
+	}
+
+	alternate, enclosures := atomLinks(e)
+	if alternate != nil {
+		item.Link = d.parseURL(elementPath(alternate), alternate.SelectAttrValue("href", ""))
+	}
+	for _, enclosure := range enclosures {
+		enclosurePath := elementPath(enclosure)
+		u := d.parseURL(enclosurePath, enclosure.SelectAttrValue("href", ""))
+		if u == nil {
+			d.warn(enclosurePath, SeverityError, "enclosure <link> has no href")
+			continue
+		}
+		item.Enclosures = append(item.Enclosures, &Enclosure{
+			URL:      u,
+			MimeType: enclosure.SelectAttrValue("type", ""),
+			Length:   d.atoi(enclosurePath, enclosure.SelectAttrValue("length", "")),
+		})
+	}
+
+	item.Categories = atomCategories(e)
+
+	// entries without an author inherit the authors of the feed
+	if item.Authors = atomAuthors(e, d); len(item.Authors) == 0 {
+		item.Authors = feedAuthors
+	}
+	item.Author = personNames(item.Authors)
+
+	date := selectNS(e, atomNS, "published")
+	if date == nil {
+		date = selectNS(e, atomNS, "updated")
+	}
+	if date != nil {
+		item.PubDate = d.parseDate(elementPath(date), date.Text())
+	}
+
+	// YouTube channel feeds describe their videos with Media RSS
+	item.Media = mediaItem(e, d)
+
+	return item, nil
+}
+
+// ParseAtom takes a reader with Atom 1.0 XML and converts it to a lenient Feed object, see ParseAtomWithOptions
+func ParseAtom(r io.Reader) (*Feed, error) {
+	return ParseAtomWithOptions(r, nil)
+}
+
+// ParseAtomWithOptions takes a reader with Atom 1.0 XML and converts it to a Feed object
+// The <subtitle> of the feed becomes its Description. The <summary> of an entry is preferred over its <content> for the Description, the <content> is kept as the Content
+func ParseAtomWithOptions(r io.Reader, opts *ParseOptions) (*Feed, error) {
+	r, err := utf8Reader(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return parseXML(r, atomLayout(), opts)
+}
+
+// atomLayout finds the <entry> elements in the <feed> root of an Atom document
+func atomLayout() *xmlLayout {
+	// entries without an author inherit the authors of the feed
+	var feedAuthors []*Person
+
+	return &xmlLayout{
+		root: func(e *etree.Element) error {
+			if e.Tag != "feed" || e.NamespaceURI() != atomNS {
+				return errors.New("xml does not have Atom <feed> tag")
+			}
+			return nil
+		},
+		container:   isRoot,
+		noContainer: errors.New("xml does not have Atom <feed> tag"),
+		isItem: func(e *etree.Element) bool {
+			return e.Tag == "entry" && e.NamespaceURI() == atomNS
+		},
+		link: func(root *etree.Element) *etree.Element {
+			alternate, _ := atomLinks(root)
+			return alternate
+		},
+		feed: func(root *etree.Element, d *diagnostics) (*Feed, error) {
+			feed, err := feedElementToFeed(root, d)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse <feed> element: %w", err)
+			}
+			feedAuthors = atomAuthors(root, d)
+			return feed, nil
+		},
+		item: func(e *etree.Element, feed *Feed, d *diagnostics) (*Item, error) {
+			return entryElementToItem(e, feed, feedAuthors, d)
+		},
+	}
+}
+
+// writeAtomLink adds a <link> with rel and href to e, unless href is nil
+func (x *xmlWriter) writeAtomLink(e *etree.Element, rel string, href *url.URL) *etree.Element {
+	if href == nil {
+		return nil
+	}
+	link := x.element(e, "link")
+	link.CreateAttr("rel", rel)
+	link.CreateAttr("href", href.String())
+	return link
+}
+
+// writeAtomCategories adds a <category> to e for every category
+func (x *xmlWriter) writeAtomCategories(e *etree.Element, categories []*Category) {
+	for _, c := range categories {
+		el := x.element(e, "category")
+		el.CreateAttr("term", c.Term)
+		setAttr(el, "scheme", c.Domain)
+	}
+}
+
+// atomUpdated returns the date a feed last changed: its LastBuildDate, falling back to its PubDate and the date of its newest item
+func atomUpdated(feed *Feed) *time.Time {
+	updated := feed.LastBuildDate
+	if updated == nil {
+		updated = feed.PubDate
+	}
+	if updated == nil {
+		for _, item := range feed.Items {
+			if item.PubDate != nil && (updated == nil || item.PubDate.After(*updated)) {
+				updated = item.PubDate
+			}
+		}
+	}
+	return updated
+}
+
+// writeAtomEntry adds an <entry> for item to e, with updated as the date of items without a PubDate
+func (x *xmlWriter) writeAtomEntry(e *etree.Element, item *Item, updated *time.Time) {
+	el := x.element(e, "entry")
+	x.element(el, "id").SetText(item.GUID)
+	x.element(el, "title").SetText(item.Title)
+	x.writeAtomLink(el, "alternate", item.Link)
+	for _, enc := range item.Enclosures {
+		link := x.writeAtomLink(el, "enclosure", enc.URL)
+		if link != nil {
+			setAttr(link, "type", enc.MimeType)
+			setAttrInt(link, "length", int64(enc.Length))
+		}
+	}
+
+	x.textDate(el, "published", item.PubDate, time.RFC3339)
+	if item.PubDate != nil {
+		updated = item.PubDate
+	}
+	x.textDate(el, "updated", updated, time.RFC3339)
+
+	for _, p := range item.Authors {
+		author := x.element(el, "author")
+		// Atom requires a <name>, which is left empty for a person known only by email address or URI
+		x.element(author, "name").SetText(p.Name)
+		x.text(author, "email", p.Email)
+		x.textURL(author, "uri", p.URI)
+	}
+	if len(item.Authors) == 0 && item.Author != "" {
+		x.text(x.element(el, "author"), "name", item.Author)
+	}
+	x.writeAtomCategories(el, item.Categories)
+
+	// the parser takes the Description from the <summary>, falling back to the <content>
+	if item.Description != item.Content {
+		summary := x.element(el, "summary")
+		summary.CreateAttr("type", "html")
+		summary.SetText(item.Description)
+	}
+	if item.Content != "" {
+		content := x.element(el, "content")
+		content.CreateAttr("type", "html")
+		content.SetText(item.Content)
+	}
+
+	x.writeMedia(el, item.Media)
+	x.writeExtensions(el, item.Extensions)
+}
+
+// WriteAtom renders feed with its Items as an Atom 1.0 document to w
+// The <id> of the feed is its FetchFrom or its Link, and its <updated> is the LastBuildDate, falling back to the PubDate and the date of the newest item.
+// Atom requires an author for each entry, but items without an Author are written without one, as the Feed has no author to inherit
+func WriteAtom(w io.Writer, feed *Feed) error {
+	x := newXMLWriter("feed")
+	e := x.root
+	e.CreateAttr("xmlns", atomNS)
+	setAttr(e, "xml:lang", feed.Language)
+
+	x.element(e, "title").SetText(feed.Title)
+	if feed.Description != "" {
+		subtitle := x.element(e, "subtitle")
+		subtitle.CreateAttr("type", "html")
+		subtitle.SetText(feed.Description)
+	}
+	x.writeAtomLink(e, "alternate", feed.Link)
+	x.writeAtomLink(e, "self", feed.FetchFrom)
+	x.writeAtomLink(e, "hub", feed.Hub)
+	x.writePaging(e, feed.Paging, "link")
+
+	id := feed.FetchFrom
+	if id == nil {
+		id = feed.Link
+	}
+	x.textURL(e, "id", id)
+	updated := atomUpdated(feed)
+	x.textDate(e, "updated", updated, time.RFC3339)
+	x.text(e, "rights", feed.Copyright)
+	x.text(e, "generator", feed.Generator)
+	if feed.Image != nil {
+		x.textURL(e, "logo", feed.Image.URL)
+	}
+	x.writeAtomCategories(e, feed.Categories)
+	x.writeExtensions(e, feed.Extensions)
+
+	for _, item := range feed.Items {
+		x.writeAtomEntry(e, item, updated)
+	}
+
+	return x.write(w)
+}
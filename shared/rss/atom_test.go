@@ -0,0 +1,135 @@
+package rss_test
+
+import (
+	_ "embed"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+//go:embed testcase/atom.xml
+var atomString string
+
+func TestBaseAtom(t *testing.T) {
+	feed, err := rss.ParseAtom(strings.NewReader(atomString))
+	if err != nil {
+		t.Fatalf("ParseAtom: %s", err)
+	}
+
+	const (
+		expectedTitle             = "dive into mark"
+		expectedLink              = "http://example.org/"
+		expectedItemGUID          = "tag:example.org,2003:3.2397"
+		expectedItemAuthor        = "Joe Gregorio"
+		expectedItemDescription   = "<p><i>[Update: The Atom draft is finished.]</i></p>"
+		expectedEnclosureLength   = 1337
+		expectedSecondAuthor      = "Mark Pilgrim"
+		expectedSecondDescription = "Just a summary"
+		expectedSecondContent     = "<p>The full text</p>"
+	)
+
+	if feed.Title != expectedTitle {
+		t.Fatalf("expected feed title %q, got %q", expectedTitle, feed.Title)
+	}
+
+	if feed.Link.String() != expectedLink {
+		t.Fatalf("expected feed link %q, got %q", expectedLink, feed.Link.String())
+	}
+
+	if feed.Generator != "Example Toolkit" {
+		t.Fatalf("expected feed generator %q, got %q", "Example Toolkit", feed.Generator)
+	}
+
+	// the logo is preferred over the icon
+	if feed.Image == nil || feed.Image.URL.String() != "http://example.org/logo.png" {
+		t.Fatalf("expected feed image from <logo>, got %+v", feed.Image)
+	}
+
+	if len(feed.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(feed.Items))
+	}
+
+	first, second := feed.Items[0], feed.Items[1]
+
+	if first.GUID != expectedItemGUID {
+		t.Fatalf("expected item guid %q, got %q", expectedItemGUID, first.GUID)
+	}
+
+	if first.Author != expectedItemAuthor {
+		t.Fatalf("expected item author %q, got %q", expectedItemAuthor, first.Author)
+	}
+
+	if first.Description != expectedItemDescription {
+		t.Fatalf("expected item description %q, got %q", expectedItemDescription, first.Description)
+	}
+
+	// <published> takes precedence over <updated>
+	if first.PubDate == nil || first.PubDate.Year() != 2003 {
+		t.Fatalf("expected item pubDate in 2003, got %v", first.PubDate)
+	}
+
+	if len(first.Enclosures) != 1 || first.Enclosures[0].Length != expectedEnclosureLength {
+		t.Fatalf("expected enclosure length %d, got %+v", expectedEnclosureLength, first.Enclosures)
+	}
+
+	if len(first.Categories) != 1 || first.Categories[0].Term != "atom" || first.Categories[0].Domain != "http://example.org/tags" {
+		t.Fatalf("unexpected item categories %+v", first.Categories)
+	}
+
+	if second.Author != expectedSecondAuthor {
+		t.Fatalf("expected item to inherit feed author %q, got %q", expectedSecondAuthor, second.Author)
+	}
+
+	if second.Description != expectedSecondDescription {
+		t.Fatalf("expected item description %q, got %q", expectedSecondDescription, second.Description)
+	}
+
+	if second.Content != expectedSecondContent {
+		t.Fatalf("expected item content %q, got %q", expectedSecondContent, second.Content)
+	}
+
+	if !second.PubDate.Equal(time.Date(2005, 8, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected item pubDate from <updated>, got %s", second.PubDate)
+	}
+}
+
+func TestAtomRejectsRSS(t *testing.T) {
+	if _, err := rss.ParseAtom(strings.NewReader(rss2String)); err == nil {
+		t.Fatal("expected an error parsing RSS as Atom")
+	}
+}
+
+func TestAtomHub(t *testing.T) {
+	const in = `<feed xmlns="http://www.w3.org/2005/Atom">
+		<title>Pushed</title>
+		<id>urn:uuid:1</id>
+		<updated>2024-01-01T00:00:00Z</updated>
+		<link rel="self" href="https://example.com/feed.atom"/>
+		<link rel="hub" href="https://hub.example.com/"/>
+	</feed>`
+
+	feed, err := rss.ParseAtom(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseAtom: %s", err)
+	}
+	if feed.Hub == nil || feed.Hub.String() != "https://hub.example.com/" || feed.FetchFrom.String() != "https://example.com/feed.atom" {
+		t.Fatalf("unexpected hub %v and self link %v", feed.Hub, feed.FetchFrom)
+	}
+
+	// the hub survives writing, as an atom:link in RSS
+	for _, format := range []rss.Format{rss.FormatRSS, rss.FormatAtom, rss.FormatJSON} {
+		var sb strings.Builder
+		if err := rss.Write(&sb, feed, format); err != nil {
+			t.Fatalf("Write %s: %s", format, err)
+		}
+		got, err := rss.Parse(strings.NewReader(sb.String()), "")
+		if err != nil {
+			t.Fatalf("Parse %s: %s", format, err)
+		}
+		if got.Hub == nil || *got.Hub != *feed.Hub {
+			t.Fatalf("%s: expected hub %s, got %v", format, feed.Hub, got.Hub)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// UnsupportedCharsetError is returned when a document declares a character encoding that cannot be converted to UTF-8
+type UnsupportedCharsetError struct {
+	Charset string
+}
+
+func (e *UnsupportedCharsetError) Error() string {
+	return fmt.Sprintf("unsupported charset %q", e.Charset)
+}
+
+// Matches the encoding in an XML declaration like <?xml version="1.0" encoding="windows-1251"?>
+var xmlEncodingRegexp = regexp.MustCompile(`^\s*<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// detectCharset returns the encoding of a document starting with head, or nil for UTF-8, along with the length of its byte order mark
+// As in RFC 7303, a byte order mark takes precedence over the charset parameter of contentType, which takes precedence over the XML declaration
+func detectCharset(head []byte, contentType string) (encoding.Encoding, int, error) {
+	switch {
+	case bytes.HasPrefix(head, []byte("\xef\xbb\xbf")):
+		return nil, 3, nil
+	case bytes.HasPrefix(head, []byte("\xff\xfe")):
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), 2, nil
+	case bytes.HasPrefix(head, []byte("\xfe\xff")):
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), 2, nil
+	// UTF-16 without a byte order mark, recognized from "<?"
+	case bytes.HasPrefix(head, []byte("<\x00?\x00")):
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), 0, nil
+	case bytes.HasPrefix(head, []byte("\x00<\x00?")):
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), 0, nil
+	}
+
+	var label string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		label = params["charset"]
+	}
+	if label == "" {
+		if m := xmlEncodingRegexp.FindSubmatch(head); m != nil {
+			label = string(m[1])
+		}
+	}
+	if label == "" {
+		return nil, 0, nil
+	}
+
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, 0, &UnsupportedCharsetError{Charset: label}
+	}
+	if enc == unicode.UTF8 {
+		return nil, 0, nil
+	}
+	return enc, 0, nil
+}
+
+// utf8Reader detects the character encoding of the document in r (see detectCharset) and returns a reader with the document converted to UTF-8
+// Byte order marks are removed. The XML declaration is left as is, so the decoders must not act on its encoding, see passthroughCharsetReader
+func utf8Reader(r io.Reader, contentType string) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return br, err
+	}
+
+	enc, bom, err := detectCharset(head, contentType)
+	if err != nil {
+		return br, err
+	}
+	if _, err := br.Discard(bom); err != nil {
+		return br, err
+	}
+	if enc == nil {
+		return br, nil
+	}
+	return enc.NewDecoder().Reader(br), nil
+}
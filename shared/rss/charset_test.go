@@ -0,0 +1,110 @@
+package rss_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// charsetFeed returns an RSS feed with title as its title and the title of its item, declaring charset if it is not empty
+func charsetFeed(charset, title string) string {
+	decl := `<?xml version="1.0"?>`
+	if charset != "" {
+		decl = `<?xml version="1.0" encoding="` + charset + `"?>`
+	}
+	return decl + `<rss version="2.0"><channel><title>` + title + `</title><link>https://example.com/</link><description>Test</description>` +
+		`<item><title>` + title + `</title></item></channel></rss>`
+}
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("failed to encode %q: %s", s, err)
+	}
+	return b
+}
+
+func TestCharsetDeclaration(t *testing.T) {
+	cases := []struct {
+		charset string
+		enc     encoding.Encoding
+		title   string
+	}{
+		{"windows-1251", charmap.Windows1251, "Новости дня"},
+		{"KOI8-R", charmap.KOI8R, "Новости дня"},
+		{"ISO-8859-1", charmap.ISO8859_1, "Café à la carte"},
+		{"Shift_JIS", japanese.ShiftJIS, "今日のニュース"},
+	}
+
+	for _, c := range cases {
+		doc := encode(t, c.enc, charsetFeed(c.charset, c.title))
+
+		feed, err := rss.ParseRSS(bytes.NewReader(doc))
+		if err != nil {
+			t.Fatalf("%s: ParseRSS: %s", c.charset, err)
+		}
+		if feed.Title != c.title || feed.Items[0].Title != c.title {
+			t.Fatalf("%s: expected title %q, got %q and %q", c.charset, c.title, feed.Title, feed.Items[0].Title)
+		}
+
+		feed, err = rss.Parse(bytes.NewReader(doc), "")
+		if err != nil {
+			t.Fatalf("%s: Parse: %s", c.charset, err)
+		}
+		if feed.Title != c.title {
+			t.Fatalf("%s: expected title %q, got %q", c.charset, c.title, feed.Title)
+		}
+	}
+}
+
+func TestCharsetContentType(t *testing.T) {
+	const title = "Новости дня"
+
+	// the header takes precedence over the declaration
+	doc := encode(t, charmap.KOI8R, charsetFeed("windows-1251", title))
+	feed, err := rss.Parse(bytes.NewReader(doc), "application/rss+xml; charset=koi8-r")
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+	if feed.Title != title {
+		t.Fatalf("expected title %q, got %q", title, feed.Title)
+	}
+}
+
+func TestCharsetBOM(t *testing.T) {
+	const title = "Grüße"
+
+	// the byte order mark takes precedence over both
+	doc := encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), charsetFeed("windows-1251", title))
+	feed, _, err := rss.Stream(bytes.NewReader(doc), "text/xml; charset=iso-8859-1", nil)
+	if err != nil {
+		t.Fatalf("Stream: %s", err)
+	}
+	if feed.Title != title {
+		t.Fatalf("expected title %q, got %q", title, feed.Title)
+	}
+
+	feed, err = rss.ParseRSS(strings.NewReader("\xef\xbb\xbf" + charsetFeed("", title)))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+	if feed.Title != title {
+		t.Fatalf("expected title %q, got %q", title, feed.Title)
+	}
+}
+
+func TestUnsupportedCharset(t *testing.T) {
+	_, err := rss.Parse(strings.NewReader(charsetFeed("x-klingon", "a")), "")
+	var charsetErr *rss.UnsupportedCharsetError
+	if !errors.As(err, &charsetErr) || charsetErr.Charset != "x-klingon" {
+		t.Fatalf("expected an UnsupportedCharsetError, got %v", err)
+	}
+}
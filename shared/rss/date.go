@@ -0,0 +1,185 @@
+package rss
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timezoneOffsets maps the timezone abbreviations seen in feeds to their offset from UTC in seconds
+// time.Parse only knows the offset of the local timezone's abbreviations and assumes UTC for everything else
+var timezoneOffsets = map[string]int{
+	// RFC 822
+	"UT": 0, "UTC": 0, "GMT": 0, "Z": 0,
+	"EST": -5 * 3600, "EDT": -4 * 3600,
+	"CST": -6 * 3600, "CDT": -5 * 3600,
+	"MST": -7 * 3600, "MDT": -6 * 3600,
+	"PST": -8 * 3600, "PDT": -7 * 3600,
+	// the rest of North America
+	"AKST": -9 * 3600, "AKDT": -8 * 3600,
+	"HST": -10 * 3600, "HDT": -9 * 3600,
+	"AST": -4 * 3600, "ADT": -3 * 3600,
+	"NST": -(3*3600 + 1800), "NDT": -(2*3600 + 1800),
+	// Europe
+	"WET": 0, "WEST": 1 * 3600,
+	"BST": 1 * 3600, // British summer time
+	"CET": 1 * 3600, "CEST": 2 * 3600,
+	"MET": 1 * 3600, "MEST": 2 * 3600,
+	"EET": 2 * 3600, "EEST": 3 * 3600,
+	"MSK": 3 * 3600,
+	// a few common ones from elsewhere
+	// IST is also Irish summer time, but feeds using it are far more often Indian
+	"IST": 5*3600 + 1800,
+	"JST": 9 * 3600, "KST": 9 * 3600,
+	"HKT": 8 * 3600, "SGT": 8 * 3600, "AWST": 8 * 3600,
+	"ACST": 9*3600 + 1800, "AEST": 10 * 3600, "AEDT": 11 * 3600,
+	"NZST": 12 * 3600, "NZDT": 13 * 3600,
+}
+
+// dateLayouts are tried in order after a date has been normalized by normalizeDate
+var dateLayouts = []string{
+	// RFC 822 and RFC 1123, without the weekday
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+	// US style month first, with the comma removed
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2 2006 15:04 -0700",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 2006 15:04",
+	"Jan 2 2006",
+	// time.UnixDate and time.RubyDate, without the weekday
+	"Jan 2 15:04:05 -0700 2006",
+	"Jan 2 15:04:05 2006",
+	// ISO 8601 and RFC 3339. fractional seconds are accepted by the layouts with seconds
+	time.RFC3339,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"2006-01",
+	"2006",
+}
+
+var weekdayPrefixes = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+var monthPrefixes = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+// isAlpha reports whether s is made of ASCII letters only
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isNumericOffset reports whether s looks like "+0200" or "-07:00"
+func isNumericOffset(s string) bool {
+	return len(s) >= 5 && (s[0] == '+' || s[0] == '-') && s[1] >= '0' && s[1] <= '9'
+}
+
+// hasNamePrefix reports whether the letters in s start with one of the 3 letter prefixes
+func hasNamePrefix(s string, prefixes []string) (string, bool) {
+	if len(s) < 3 || !isAlpha(s) {
+		return "", false
+	}
+	lower := strings.ToLower(s)
+	for _, p := range prefixes {
+		if strings.HasPrefix(lower, p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// formatOffset formats an offset in seconds as "+hhmm"
+func formatOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset%3600/60)
+}
+
+// normalizeDate rewrites the broken and ambiguous parts of a date into something dateLayouts can match:
+// whitespace is collapsed, commas, weekdays and trailing comments like "(UTC)" are dropped, month names are shortened to 3 letters,
+// and timezone abbreviations are replaced with numeric offsets (unknown abbreviations are dropped, leaving the date in UTC)
+func normalizeDate(s string) string {
+	if i := strings.IndexByte(s, '('); i > 0 {
+		s = s[:i]
+	}
+	s = strings.ReplaceAll(s, ",", " ")
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// ISO 8601 is a single field (or two with a space instead of T), only the case of T and Z can be off
+	if len(fields[0]) >= 4 && fields[0][0] >= '0' && fields[0][0] <= '9' && strings.Contains(fields[0], "-") {
+		fields[0] = strings.ToUpper(fields[0])
+	}
+
+	out := make([]string, 0, len(fields))
+	for i, f := range fields {
+		if _, ok := hasNamePrefix(f, weekdayPrefixes); ok && i == 0 {
+			continue
+		}
+
+		if offset, ok := timezoneOffsets[strings.ToUpper(f)]; ok {
+			// "+0000 GMT" carries the offset twice
+			if len(out) > 0 && isNumericOffset(out[len(out)-1]) {
+				continue
+			}
+			out = append(out, formatOffset(offset))
+			continue
+		}
+
+		// "GMT+0200" and "UTC-05:00"
+		if upper := strings.ToUpper(f); (strings.HasPrefix(upper, "GMT") || strings.HasPrefix(upper, "UTC")) && isNumericOffset(f[3:]) {
+			out = append(out, f[3:])
+			continue
+		}
+
+		if month, ok := hasNamePrefix(f, monthPrefixes); ok {
+			out = append(out, month)
+			continue
+		}
+
+		if isAlpha(f) && i == len(fields)-1 {
+			continue // unknown timezone
+		}
+
+		out = append(out, f)
+	}
+
+	return strings.Join(out, " ")
+}
+
+// ParseDate parses a date in any of the formats found in feeds: RFC 822 and RFC 1123 (with or without seconds and weekday),
+// ISO 8601 and RFC 3339, and a number of common broken forms. Timezone abbreviations are mapped to their real offsets
+// Dates without a timezone are assumed to be in UTC
+func ParseDate(dateStr string) (time.Time, error) {
+	normalized := normalizeDate(dateStr)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, normalized); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse date %q", dateStr)
+}
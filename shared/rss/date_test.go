@@ -0,0 +1,77 @@
+package rss_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+func TestParseDate(t *testing.T) {
+	edt := time.FixedZone("EDT", -4*3600)
+	cet := time.FixedZone("CET", 1*3600)
+
+	cases := []struct {
+		in       string
+		expected time.Time
+	}{
+		{"Mon, 02 Jan 2006 15:04:05 -0700", time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC)},
+		{"Fri, 21 Jul 2023 09:04 EDT", time.Date(2023, 7, 21, 9, 4, 0, 0, edt)},
+		{"Fri, 21 Jul 2023 09:04:00 edt", time.Date(2023, 7, 21, 9, 4, 0, 0, edt)},
+		{"21 Jul 2023 09:04:00 EDT", time.Date(2023, 7, 21, 9, 4, 0, 0, edt)},
+		{"Tue, 2 May 2023 8:05:00 GMT", time.Date(2023, 5, 2, 8, 5, 0, 0, time.UTC)},
+		{"Thurs, 7 Sept 2023 10:00:00 CET", time.Date(2023, 9, 7, 10, 0, 0, 0, cet)},
+		{"Wed, 12 July 2023 10:00:00 +0000 GMT", time.Date(2023, 7, 12, 10, 0, 0, 0, time.UTC)},
+		{"Wed, 12 Jul 2023 10:00:00 +0000 (UTC)", time.Date(2023, 7, 12, 10, 0, 0, 0, time.UTC)},
+		{"Wed, 12 Jul 2023 10:00:00 IST", time.Date(2023, 7, 12, 4, 30, 0, 0, time.UTC)},
+		{"Wed, 12 Jul 23 10:00 PDT", time.Date(2023, 7, 12, 17, 0, 0, 0, time.UTC)},
+		{"Wed, 12 Jul 2023 10:00:00 GMT+0200", time.Date(2023, 7, 12, 8, 0, 0, 0, time.UTC)},
+		{"Wed, 12 Jul 2023 10:00:00", time.Date(2023, 7, 12, 10, 0, 0, 0, time.UTC)},
+		{"July 12, 2023 10:00:00 EST", time.Date(2023, 7, 12, 15, 0, 0, 0, time.UTC)},
+		{"Jul 12 2023", time.Date(2023, 7, 12, 0, 0, 0, 0, time.UTC)},
+		{"Wed Jul 12 10:00:00 -0700 2023", time.Date(2023, 7, 12, 17, 0, 0, 0, time.UTC)},
+		{"2023-07-12T10:00:00Z", time.Date(2023, 7, 12, 10, 0, 0, 0, time.UTC)},
+		{"2023-07-12T10:00:00.123+02:00", time.Date(2023, 7, 12, 8, 0, 0, 123000000, time.UTC)},
+		{"2023-07-12t10:00:00z", time.Date(2023, 7, 12, 10, 0, 0, 0, time.UTC)},
+		{"2023-07-12 10:00:00 UTC", time.Date(2023, 7, 12, 10, 0, 0, 0, time.UTC)},
+		{"2023-07-12", time.Date(2023, 7, 12, 0, 0, 0, 0, time.UTC)},
+		{"  Wed,  12 Jul 2023   10:00:00 GMT  ", time.Date(2023, 7, 12, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		got, err := rss.ParseDate(c.in)
+		if err != nil {
+			t.Fatalf("ParseDate(%q): %s", c.in, err)
+		}
+		if !got.Equal(c.expected) {
+			t.Fatalf("ParseDate(%q): expected %s, got %s", c.in, c.expected, got)
+		}
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, in := range []string{"", "yesterday", "32 Foo 2023", "not a date at all"} {
+		if got, err := rss.ParseDate(in); err == nil {
+			t.Fatalf("ParseDate(%q): expected error, got %s", in, got)
+		}
+	}
+}
+
+func TestUnparseablePubDateIsNil(t *testing.T) {
+	const doc = `<rss version="2.0"><channel><title>a</title><link>b</link><description>c</description>
+<item><title>no date</title></item>
+<item><title>bad date</title><pubDate>sometime last week</pubDate></item>
+</channel></rss>`
+
+	feed, err := rss.ParseRSS(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	for _, item := range feed.Items {
+		if item.PubDate != nil {
+			t.Fatalf("expected nil pubDate for item %q, got %s", item.Title, item.PubDate)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package rss
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// Severity is how much a problem in a feed affects the parsed result
+type Severity int
+
+const (
+	// The feed breaks the spec, but nothing was lost
+	SeverityWarning Severity = iota
+	// A value or an item could not be parsed and was dropped
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Warning is a problem found while parsing a feed
+type Warning struct {
+	// The location of the problem, like "/rss/channel/item[3]/enclosure" in XML feeds or "items[2].url" in JSON Feeds
+	Path     string
+	Problem  string
+	Severity Severity
+}
+
+func (w *Warning) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", w.Path, w.Problem, w.Severity)
+}
+
+// StrictError is returned by the parsers in strict mode when the feed has any problem
+type StrictError struct {
+	Warnings []*Warning
+}
+
+func (e *StrictError) Error() string {
+	if len(e.Warnings) == 1 {
+		return fmt.Sprintf("feed violates the spec: %s", e.Warnings[0])
+	}
+	return fmt.Sprintf("feed violates the spec in %d places, first: %s", len(e.Warnings), e.Warnings[0])
+}
+
+// ParseOptions configures the parsers. A nil *ParseOptions is the same as the zero value, which is lenient
+type ParseOptions struct {
+	// Strict makes the parsers reject feeds with any Warning, returning a *StrictError.
+	// In lenient mode the feed is returned with its problems in Feed.Warnings
+	Strict bool
+	// BaseURL is the URL the document was fetched from, usually the Feed.FetchFrom. Relative URLs in the feed are resolved against it
+	// when neither xml:base nor the link of the channel make them absolute
+	BaseURL *url.URL
+	// Extensions has the handlers of namespace extensions, DefaultExtensions if nil
+	Extensions *ExtensionRegistry
+}
+
+// baseURL returns the BaseURL of opts, which may be nil
+func (o *ParseOptions) baseURL() *url.URL {
+	if o == nil {
+		return nil
+	}
+	return o.BaseURL
+}
+
+// extensions returns the extension registry of opts, which may be nil
+func (o *ParseOptions) extensions() *ExtensionRegistry {
+	if o == nil || o.Extensions == nil {
+		return DefaultExtensions
+	}
+	return o.Extensions
+}
+
+// diagnostics collects the problems found during a single parse
+type diagnostics struct {
+	warnings []*Warning
+}
+
+func (d *diagnostics) warn(path string, severity Severity, format string, args ...any) {
+	d.warnings = append(d.warnings, &Warning{Path: path, Problem: fmt.Sprintf(format, args...), Severity: severity})
+}
+
+// parseURL parses raw as a URL, warning about the element at path and returning nil if it is malformed
+// An empty raw returns nil without a warning, the caller decides whether the URL is required
+func (d *diagnostics) parseURL(path, raw string) *url.URL {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		d.warn(path, SeverityError, "malformed url %q", raw)
+		return nil
+	}
+	return u
+}
+
+// atoi parses raw as an int, warning about the element at path and returning 0 if it is malformed
+// An empty raw returns 0 without a warning
+func (d *diagnostics) atoi(path, raw string) int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	i, err := strconv.Atoi(raw)
+	if err != nil {
+		d.warn(path, SeverityError, "%q is not a number", raw)
+		return 0
+	}
+	return i
+}
+
+// parseDate parses raw with ParseDate, warning about the element at path and returning nil if it is malformed
+// An empty raw returns nil without a warning
+func (d *diagnostics) parseDate(path, raw string) *time.Time {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	t, err := ParseDate(raw)
+	if err != nil {
+		d.warn(path, SeverityError, "unrecognized date %q", raw)
+		return nil
+	}
+	return &t
+}
+
+// finish applies opts to the parsed feed: it is rejected in strict mode if there were any problems, and gets them as its Warnings otherwise
+func (d *diagnostics) finish(feed *Feed, opts *ParseOptions) (*Feed, error) {
+	if opts != nil && opts.Strict && len(d.warnings) > 0 {
+		return nil, &StrictError{Warnings: d.warnings}
+	}
+	feed.Warnings = d.warnings
+	return feed, nil
+}
+
+// elementPath returns the path of e from the document root, like "/rss/channel/item[3]/enclosure"
+// The 1-based position of an element is added when its parent has several children of the same name
+func elementPath(e *etree.Element) string {
+	var parts []string
+	for ; e != nil && e.Parent() != nil; e = e.Parent() {
+		part := e.FullTag()
+		n, pos := 0, 0
+		for c := range e.Parent().ChildElementsSeq() {
+			if c.Tag == e.Tag && c.Space == e.Space {
+				n++
+				if c == e {
+					pos = n
+				}
+			}
+		}
+		if n > 1 {
+			part = fmt.Sprintf("%s[%d]", part, pos)
+		}
+		parts = append(parts, part)
+	}
+
+	var sb strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		sb.WriteString("/")
+		sb.WriteString(parts[i])
+	}
+	return sb.String()
+}
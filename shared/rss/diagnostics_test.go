@@ -0,0 +1,150 @@
+package rss_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+const brokenFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+   <channel>
+      <title>Broken</title>
+      <link>https://example.com/</link>
+      <description>A feed with problems</description>
+      <ttl>an hour</ttl>
+      <item>
+         <title>Fine</title>
+         <enclosure url="https://example.com/a.mp3" type="audio/mpeg" length="1"/>
+      </item>
+      <item>
+         <link>https://example.com/2</link>
+         <pubDate>yesterday</pubDate>
+         <enclosure url="https://example.com/b.mp3" length="big"/>
+         <enclosure type="audio/mpeg" length="1"/>
+      </item>
+   </channel>
+</rss>`
+
+func TestLenientWarnings(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(brokenFeed))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	if len(feed.Items) != 2 || len(feed.Items[1].Enclosures) != 1 {
+		t.Fatalf("expected 2 items and the enclosure without a url to be dropped, got %+v", feed.Items)
+	}
+
+	expected := []rss.Warning{
+		{Path: "/rss/channel/ttl", Severity: rss.SeverityError},
+		{Path: "/rss/channel/item[2]/pubDate", Severity: rss.SeverityError},
+		{Path: "/rss/channel/item[2]", Severity: rss.SeverityWarning},
+		{Path: "/rss/channel/item[2]/enclosure[1]", Severity: rss.SeverityWarning},
+		{Path: "/rss/channel/item[2]/enclosure[1]", Severity: rss.SeverityError},
+		{Path: "/rss/channel/item[2]/enclosure[2]", Severity: rss.SeverityError},
+	}
+
+	if len(feed.Warnings) != len(expected) {
+		t.Fatalf("expected %d warnings, got %v", len(expected), feed.Warnings)
+	}
+
+	for i, w := range feed.Warnings {
+		if w.Path != expected[i].Path || w.Severity != expected[i].Severity || w.Problem == "" {
+			t.Errorf("warning %d: expected %s at %q, got %s", i, expected[i].Severity, expected[i].Path, w)
+		}
+	}
+}
+
+func TestStrictRejects(t *testing.T) {
+	strict := &rss.ParseOptions{Strict: true}
+
+	_, err := rss.ParseWithOptions(strings.NewReader(brokenFeed), "", strict)
+	var strictErr *rss.StrictError
+	if !errors.As(err, &strictErr) {
+		t.Fatalf("expected a StrictError, got %v", err)
+	}
+
+	if len(strictErr.Warnings) != 6 {
+		t.Fatalf("expected 6 warnings, got %v", strictErr.Warnings)
+	}
+
+	// valid feeds are accepted in strict mode
+	if _, err := rss.ParseRSSWithOptions(strings.NewReader(rss2String), strict); err != nil {
+		t.Fatalf("ParseRSSWithOptions: %s", err)
+	}
+}
+
+func TestJSONFeedWarnings(t *testing.T) {
+	const feed = `{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "Broken",
+		"items": [
+			{"id": "1", "url": "https://example.com/1"},
+			{"id": {}, "url": "https://example.com/2"},
+			{"id": "3", "date_published": "never", "attachments": [{"url": "https://example.com/a.mp3"}]}
+		]
+	}`
+
+	parsed, err := rss.ParseJSONFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("ParseJSONFeed: %s", err)
+	}
+
+	if len(parsed.Items) != 2 {
+		t.Fatalf("expected the item with a bad id to be dropped, got %d items", len(parsed.Items))
+	}
+
+	paths := make([]string, len(parsed.Warnings))
+	for i, w := range parsed.Warnings {
+		paths[i] = w.Path
+	}
+
+	if strings.Join(paths, " ") != "items[1] items[2].date_published items[2].attachments[0]" {
+		t.Fatalf("unexpected warnings %v", parsed.Warnings)
+	}
+}
+
+func TestStrictRejectsExtensions(t *testing.T) {
+	strict := &rss.ParseOptions{Strict: true}
+
+	for _, tc := range []struct {
+		element string
+		path    string
+	}{
+		{`<itunes:episode>abc</itunes:episode>`, "/rss/channel/item[1]/itunes:episode"},
+		{`<itunes:season>two</itunes:season>`, "/rss/channel/item[1]/itunes:season"},
+		{`<itunes:duration>forever</itunes:duration>`, "/rss/channel/item[1]/itunes:duration"},
+		{`<dc:date>the other day</dc:date>`, "/rss/channel/item[1]/dc:date"},
+		{`<media:content url="https://example.com/a.jpg" width="wide"/>`, "/rss/channel/item[1]/media:content"},
+		{`<media:thumbnail url="https://example.com/a.jpg" height="tall"/>`, "/rss/channel/item[1]/media:thumbnail"},
+		{`<podcast:transcript url="http://[::1" type="text/html"/>`, "/rss/channel/item[1]/podcast:transcript"},
+	} {
+		doc := `<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:dc="http://purl.org/dc/elements/1.1/" ` +
+			`xmlns:media="http://search.yahoo.com/mrss/" xmlns:podcast="https://podcastindex.org/namespace/1.0">` +
+			`<channel><title>T</title><link>https://example.com/</link><description>D</description>` +
+			`<item><title>Item</title>` + tc.element + `</item></channel></rss>`
+
+		_, err := rss.ParseRSSWithOptions(strings.NewReader(doc), strict)
+		var strictErr *rss.StrictError
+		if !errors.As(err, &strictErr) {
+			t.Errorf("%s: expected a StrictError, got %v", tc.element, err)
+			continue
+		}
+		if len(strictErr.Warnings) != 1 || strictErr.Warnings[0].Path != tc.path {
+			t.Errorf("%s: expected a warning at %q, got %v", tc.element, tc.path, strictErr.Warnings)
+		}
+
+		// lenient mode keeps the item without the value
+		feed, err := rss.ParseRSS(strings.NewReader(doc))
+		if err != nil {
+			t.Errorf("%s: ParseRSS: %s", tc.element, err)
+			continue
+		}
+		if len(feed.Items) != 1 || len(feed.Warnings) != 1 {
+			t.Errorf("%s: expected one item and one warning, got %d and %v", tc.element, len(feed.Items), feed.Warnings)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package rss
+
+import (
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// The Dublin Core elements namespace. See https://www.dublincore.org/specifications/dublin-core/dces/
+const dcNS = "http://purl.org/dc/elements/1.1/"
+
+// dcText returns the trimmed text of every Dublin Core element named tag under e, joined with ", "
+func dcText(e *etree.Element, tag string) string {
+	var values []string
+	for el := range childrenNS(e, dcNS, tag) {
+		if v := strings.TrimSpace(el.Text()); v != "" {
+			values = append(values, v)
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// dcSubjects returns every <dc:subject> under e as a category
+func dcSubjects(e *etree.Element) []*Category {
+	var categories []*Category
+	for el := range childrenNS(e, dcNS, "subject") {
+		if term := strings.TrimSpace(el.Text()); term != "" {
+			categories = append(categories, &Category{Term: term})
+		}
+	}
+	return categories
+}
+
+// applyDCChannel fills in the rights, publisher, language and categories of a feed from the Dublin Core elements of e, without overwriting values from native elements
+func applyDCChannel(e *etree.Element, feed *Feed) {
+	if feed.Copyright == "" {
+		feed.Copyright = dcText(e, "rights")
+	}
+	if feed.Publisher == "" {
+		feed.Publisher = dcText(e, "publisher")
+	}
+	if feed.Language == "" {
+		feed.Language = strings.TrimSpace(selectNS(e, dcNS, "language").NotNil().Text())
+	}
+	if len(feed.Categories) == 0 {
+		feed.Categories = dcSubjects(e)
+	}
+}
+
+// applyDCItem fills in the author, publication date and categories of an item from the Dublin Core elements of e, without overwriting values from native elements
+// Many CMSes, WordPress included, use <dc:creator> instead of <author>
+func applyDCItem(e *etree.Element, item *Item, d *diagnostics) {
+	if item.Author == "" {
+		item.Author = dcText(e, "creator")
+	}
+	if len(item.Authors) == 0 {
+		item.Authors = parsePersons(childrenNS(e, dcNS, "creator"))
+	}
+	if date := selectNS(e, dcNS, "date"); item.PubDate == nil && date != nil {
+		item.PubDate = d.parseDate(elementPath(date), date.Text())
+	}
+	if len(item.Categories) == 0 {
+		item.Categories = dcSubjects(e)
+	}
+}
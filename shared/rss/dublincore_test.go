@@ -0,0 +1,62 @@
+package rss_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+const dcFeed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+   <channel>
+      <title>WordPress Blog</title>
+      <link>https://blog.example.com</link>
+      <description>Just another WordPress site</description>
+      <dc:language>en-GB</dc:language>
+      <dc:rights>CC BY 4.0</dc:rights>
+      <dc:publisher>Example Media Ltd</dc:publisher>
+      <item>
+         <title>Hello world!</title>
+         <dc:creator><![CDATA[admin]]></dc:creator>
+         <dc:date>2023-07-21T09:04:00+01:00</dc:date>
+      </item>
+      <item>
+         <title>Native elements win</title>
+         <author>editor@example.com (Editor)</author>
+         <pubDate>Fri, 21 Jul 2023 10:00:00 GMT</pubDate>
+         <dc:creator>admin</dc:creator>
+         <dc:date>2001-01-01</dc:date>
+      </item>
+   </channel>
+</rss>`
+
+func TestDublinCore(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(dcFeed))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	if feed.Language != "en-GB" || feed.Copyright != "CC BY 4.0" || feed.Publisher != "Example Media Ltd" {
+		t.Fatalf("unexpected channel language %q, rights %q, publisher %q", feed.Language, feed.Copyright, feed.Publisher)
+	}
+
+	first, second := feed.Items[0], feed.Items[1]
+
+	if first.Author != "admin" {
+		t.Fatalf("expected author %q from <dc:creator>, got %q", "admin", first.Author)
+	}
+
+	if first.PubDate == nil || !first.PubDate.Equal(time.Date(2023, 7, 21, 8, 4, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected pubDate from <dc:date>: %v", first.PubDate)
+	}
+
+	if second.Author != "editor@example.com (Editor)" {
+		t.Fatalf("expected <author> to take precedence over <dc:creator>, got %q", second.Author)
+	}
+
+	if second.PubDate == nil || second.PubDate.Year() != 2023 {
+		t.Fatalf("expected <pubDate> to take precedence over <dc:date>, got %v", second.PubDate)
+	}
+}
@@ -0,0 +1,161 @@
+package rss
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/beevik/etree"
+)
+
+// The namespaces the parser reads itself. Elements in other namespaces are extensions
+var builtinNamespaces = map[string]bool{
+	"":        true,
+	atomNS:    true,
+	contentNS: true,
+	dcNS:      true,
+	fhNS:      true,
+	itunesNS:  true,
+	mediaNS:   true,
+	podcastNS: true,
+	rdfNS:     true,
+	rss1NS:    true,
+	rss090NS:  true,
+}
+
+// Extensions holds the data of the namespace extensions of a feed or an item, keyed by namespace URI
+// A registered ExtensionHandler stores whatever it parsed under its namespace. Elements in namespaces that are neither built in nor registered are kept as []*RawElement
+type Extensions map[string]any
+
+// Raw returns the elements in the namespace uri that were kept because it has no handler
+func (ext Extensions) Raw(uri string) []*RawElement {
+	raw, _ := ext[uri].([]*RawElement)
+	return raw
+}
+
+// ExtensionAs returns the data stored under the namespace uri, and whether there is any of type T
+func ExtensionAs[T any](ext Extensions, uri string) (T, bool) {
+	v, ok := ext[uri].(T)
+	return v, ok
+}
+
+// RawElement is an element in an unknown namespace, kept as it was in the document
+type RawElement struct {
+	Namespace string
+	Name      string
+	// The prefix of the element in the document, which the writers reuse when it is free
+	Prefix string
+	// The attributes of the element, without namespace declarations. Attributes with a prefix keep it, like "rdf:resource"
+	Attrs map[string]string
+	// The namespace URIs of the prefixes of the attributes, like "rdf" for "rdf:resource"
+	AttrNamespaces map[string]string
+	// The trimmed text of the element
+	Text     string
+	Children []*RawElement
+}
+
+// ExtensionHandler parses the elements of a namespace. Either function may be nil
+// The functions get the whole channel or item element, which has at least one child in the namespace, and store what they parsed in ext
+type ExtensionHandler struct {
+	// Channel is called with the <channel> of RSS 2.0 and RSS 1.0, or the <feed> of Atom
+	Channel func(e *etree.Element, ext Extensions) error
+	// Item is called with an <item> or an Atom <entry>
+	Item func(e *etree.Element, ext Extensions) error
+}
+
+// ExtensionRegistry maps namespace URIs to their handlers. It is safe for concurrent use
+type ExtensionRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string]*ExtensionHandler
+}
+
+func NewExtensionRegistry() *ExtensionRegistry {
+	return &ExtensionRegistry{handlers: map[string]*ExtensionHandler{}}
+}
+
+// Register sets the handler of the namespace uri, replacing the previous one. A nil h removes it
+// Built-in namespaces can be registered too, their handlers run after the built-in parsing
+func (r *ExtensionRegistry) Register(uri string, h *ExtensionHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if h == nil {
+		delete(r.handlers, uri)
+		return
+	}
+	r.handlers[uri] = h
+}
+
+func (r *ExtensionRegistry) handler(uri string) *ExtensionHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.handlers[uri]
+}
+
+// DefaultExtensions is the registry used when ParseOptions.Extensions is nil
+var DefaultExtensions = NewExtensionRegistry()
+
+// RegisterExtension registers h for the namespace uri in DefaultExtensions, see ExtensionRegistry.Register
+func RegisterExtension(uri string, h *ExtensionHandler) {
+	DefaultExtensions.Register(uri, h)
+}
+
+// rawElement copies e and its children to a RawElement
+func rawElement(e *etree.Element) *RawElement {
+	raw := &RawElement{Namespace: e.NamespaceURI(), Name: e.Tag, Prefix: e.Space, Text: strings.TrimSpace(e.Text())}
+	for _, a := range e.Attr {
+		if a.Space == "xmlns" || (a.Space == "" && a.Key == "xmlns") {
+			continue
+		}
+		if raw.Attrs == nil {
+			raw.Attrs = map[string]string{}
+		}
+		raw.Attrs[a.FullKey()] = a.Value
+		if a.Space != "" && a.Space != "xml" {
+			if raw.AttrNamespaces == nil {
+				raw.AttrNamespaces = map[string]string{}
+			}
+			raw.AttrNamespaces[a.Space] = a.NamespaceURI()
+		}
+	}
+	for c := range e.ChildElementsSeq() {
+		raw.Children = append(raw.Children, rawElement(c))
+	}
+	return raw
+}
+
+// parseExtensions runs the handlers of the namespaces of the children of e, a channel or an item as told by isItem, and keeps the children in unknown namespaces.
+// Returns nil if nothing was stored
+func parseExtensions(e *etree.Element, r *ExtensionRegistry, isItem bool, d *diagnostics) Extensions {
+	ext := Extensions{}
+	handled := map[string]bool{}
+	for c := range e.ChildElementsSeq() {
+		uri := namespaceURI(c)
+		if h := r.handler(uri); h != nil {
+			if handled[uri] {
+				continue
+			}
+			handled[uri] = true
+
+			parse := h.Channel
+			if isItem {
+				parse = h.Item
+			}
+			if parse == nil {
+				continue
+			}
+			if err := parse(e, ext); err != nil {
+				d.warn(elementPath(c), SeverityError, "failed to parse extension %s: %s", uri, err)
+			}
+			continue
+		}
+
+		if !builtinNamespaces[uri] {
+			ext[uri] = append(ext.Raw(uri), rawElement(c))
+		}
+	}
+
+	if len(ext) == 0 {
+		return nil
+	}
+	return ext
+}
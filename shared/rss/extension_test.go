@@ -0,0 +1,140 @@
+package rss_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+const (
+	slashNS = "http://purl.org/rss/1.0/modules/slash/"
+	wfwNS   = "http://wellformedweb.org/CommentAPI/"
+	geoNS   = "http://www.w3.org/2003/01/geo/wgs84_pos#"
+)
+
+const extensionFeed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:slash="http://purl.org/rss/1.0/modules/slash/" xmlns:wfw="http://wellformedweb.org/CommentAPI/" xmlns:geo="http://www.w3.org/2003/01/geo/wgs84_pos#">
+   <channel>
+      <title>Extensions</title>
+      <link>https://example.com</link>
+      <description>Namespaces the parser does not know</description>
+      <geo:Point>
+         <geo:lat>55.75</geo:lat>
+         <geo:long>37.62</geo:long>
+      </geo:Point>
+      <item>
+         <title>First</title>
+         <slash:comments>12</slash:comments>
+         <wfw:commentRss>https://example.com/1/comments</wfw:commentRss>
+      </item>
+      <item>
+         <title>Second</title>
+         <slash:comments>many</slash:comments>
+      </item>
+   </channel>
+</rss>`
+
+// slashComments is the typed data of the slash namespace in the tests
+type slashComments int
+
+func TestExtensionRegistry(t *testing.T) {
+	registry := rss.NewExtensionRegistry()
+	registry.Register(slashNS, &rss.ExtensionHandler{
+		Item: func(e *etree.Element, ext rss.Extensions) error {
+			for c := range e.ChildElementsSeq() {
+				if c.Tag == "comments" && c.NamespaceURI() == slashNS {
+					n, err := strconv.Atoi(c.Text())
+					if err != nil {
+						return errors.New("comments is not a number")
+					}
+					ext[slashNS] = slashComments(n)
+				}
+			}
+			return nil
+		},
+	})
+
+	feed, err := rss.ParseRSSWithOptions(strings.NewReader(extensionFeed), &rss.ParseOptions{Extensions: registry})
+	if err != nil {
+		t.Fatalf("ParseRSSWithOptions: %s", err)
+	}
+
+	if comments, ok := rss.ExtensionAs[slashComments](feed.Items[0].Extensions, slashNS); !ok || comments != 12 {
+		t.Fatalf("expected 12 comments, got %v", feed.Items[0].Extensions[slashNS])
+	}
+	if _, ok := feed.Items[1].Extensions[slashNS]; ok || len(feed.Warnings) != 1 || feed.Warnings[0].Path != "/rss/channel/item[2]/slash:comments" {
+		t.Fatalf("expected a warning for the second item, got %v and %v", feed.Items[1].Extensions, feed.Warnings)
+	}
+
+	// unregistered namespaces are kept raw
+	raw := feed.Items[0].Extensions.Raw(wfwNS)
+	if len(raw) != 1 || raw[0].Name != "commentRss" || raw[0].Text != "https://example.com/1/comments" {
+		t.Fatalf("expected a raw <wfw:commentRss>, got %+v", raw)
+	}
+	point := feed.Extensions.Raw(geoNS)
+	if len(point) != 1 || len(point[0].Children) != 2 || point[0].Children[1].Name != "long" || point[0].Children[1].Text != "37.62" {
+		t.Fatalf("expected a raw <geo:Point>, got %+v", point)
+	}
+
+	_, err = rss.ParseRSSWithOptions(strings.NewReader(extensionFeed), &rss.ParseOptions{Strict: true, Extensions: registry})
+	if _, ok := err.(*rss.StrictError); !ok {
+		t.Fatalf("expected a *StrictError, got %v", err)
+	}
+
+	// without the handler, slash is raw too
+	feed, err = rss.ParseRSS(strings.NewReader(extensionFeed))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+	if raw := feed.Items[1].Extensions.Raw(slashNS); len(raw) != 1 || raw[0].Text != "many" {
+		t.Fatalf("expected a raw <slash:comments>, got %+v", raw)
+	}
+}
+
+func TestExtensionBuiltin(t *testing.T) {
+	// namespaces the parser knows are not kept
+	feed, err := rss.ParseRSS(strings.NewReader(podcastString))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+	if feed.Extensions != nil || feed.Items[1].Extensions != nil {
+		t.Fatalf("expected no extensions, got %v and %v", feed.Extensions, feed.Items[1].Extensions)
+	}
+
+	// but can be handled as well
+	const itunesNS = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	registry := rss.NewExtensionRegistry()
+	registry.Register(itunesNS, &rss.ExtensionHandler{
+		Channel: func(e *etree.Element, ext rss.Extensions) error {
+			ext[itunesNS] = e.Tag
+			return nil
+		},
+	})
+	feed, err = rss.ParseRSSWithOptions(strings.NewReader(podcastString), &rss.ParseOptions{Extensions: registry})
+	if err != nil {
+		t.Fatalf("ParseRSSWithOptions: %s", err)
+	}
+	if tag, _ := rss.ExtensionAs[string](feed.Extensions, itunesNS); tag != "channel" || feed.ITunes == nil || feed.Items[1].Extensions != nil {
+		t.Fatalf("expected the channel to be handled, got %v", feed.Extensions)
+	}
+}
+
+func TestExtensionAtom(t *testing.T) {
+	const ytNS = "http://www.youtube.com/xml/schemas/2015"
+
+	feed, err := rss.ParseAtom(strings.NewReader(youtubeString))
+	if err != nil {
+		t.Fatalf("ParseAtom: %s", err)
+	}
+
+	if raw := feed.Extensions.Raw(ytNS); len(raw) != 1 || raw[0].Name != "channelId" {
+		t.Fatalf("expected a raw <yt:channelId>, got %+v", raw)
+	}
+	if raw := feed.Items[0].Extensions.Raw(ytNS); len(raw) != 1 || raw[0].Name != "videoId" || raw[0].Text != "dQw4w9WgXcQ" {
+		t.Fatalf("expected a raw <yt:videoId>, got %+v", raw)
+	}
+}
@@ -0,0 +1,71 @@
+package rss
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// rssGUID sets the GUID of item from the <guid> under e, and uses it as the Link of the item if it is a permalink and the item has no <link>
+// isPermaLink defaults to true, but a <guid> without the attribute that is not an absolute URL is taken as an opaque identifier, as many feeds omit it
+func rssGUID(e *etree.Element, item *Item, d *diagnostics) {
+	guid := rssSelect(e, "guid")
+	if guid == nil {
+		return
+	}
+	item.GUID = strings.TrimSpace(guid.Text())
+	if item.GUID == "" {
+		return
+	}
+
+	isPermaLink := guid.SelectAttrValue("isPermaLink", "")
+	if isPermaLink == "false" {
+		return
+	}
+
+	u, err := url.Parse(item.GUID)
+	if err != nil || !u.IsAbs() {
+		if isPermaLink == "true" {
+			d.warn(elementPath(guid), SeverityWarning, "<guid> is a permalink, but %q is not a URL", item.GUID)
+		}
+		return
+	}
+
+	item.GUIDIsPermaLink = true
+	if item.Link == nil {
+		item.Link = u
+	}
+}
+
+// synthesizeGUID returns an identity for an item without a GUID, so the same item gets the same GUID on every fetch
+// It is derived from the link of the feed and the link, title, first enclosure and publication date of the item,
+// and from its description only if it has neither a link nor a title
+func synthesizeGUID(item *Item) string {
+	var parts []string
+	if item.Feed != nil && item.Feed.Link != nil {
+		parts = append(parts, item.Feed.Link.String())
+	} else {
+		parts = append(parts, "")
+	}
+
+	var link, enclosure, pubDate, description string
+	if item.Link != nil {
+		link = item.Link.String()
+	}
+	if len(item.Enclosures) > 0 && item.Enclosures[0].URL != nil {
+		enclosure = item.Enclosures[0].URL.String()
+	}
+	if item.PubDate != nil {
+		pubDate = item.PubDate.UTC().Format(time.RFC3339)
+	}
+	if link == "" && item.Title == "" {
+		description = item.Description
+	}
+	parts = append(parts, link, item.Title, enclosure, pubDate, description)
+
+	return fmt.Sprintf("urn:fedup:%x", sha256.Sum256([]byte(strings.Join(parts, "\x00"))))
+}
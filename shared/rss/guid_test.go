@@ -0,0 +1,94 @@
+package rss_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+const guidFeed = `<rss version="2.0"><channel><title>GUIDs</title><link>https://example.com/</link><description>Test</description>
+<item><title>Permalink</title><guid>https://example.com/posts/1</guid></item>
+<item><title>Opaque</title><link>https://example.com/posts/2</link><guid isPermaLink="false">https://example.com/?p=2</guid></item>
+<item><title>Not a URL</title><guid>post-3</guid></item>
+<item><title>Wrong</title><guid isPermaLink="true">post-4</guid></item>
+<item><title>No guid</title><link>https://example.com/posts/5</link><pubDate>Sun, 19 May 2002 15:21:36 GMT</pubDate></item>
+<item><description>No guid, link or title</description></item>
+</channel></rss>`
+
+func TestGUID(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(guidFeed))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+	items := feed.Items
+
+	if !items[0].GUIDIsPermaLink || items[0].Link.String() != "https://example.com/posts/1" {
+		t.Fatalf("expected the guid to be the link, got %+v", items[0])
+	}
+	if items[1].GUIDIsPermaLink || items[1].Link.String() != "https://example.com/posts/2" {
+		t.Fatalf("expected an opaque guid, got %+v", items[1])
+	}
+	if items[2].GUIDIsPermaLink || items[2].GUID != "post-3" || items[2].Link != nil {
+		t.Fatalf("expected an opaque guid without isPermaLink, got %+v", items[2])
+	}
+	if items[3].GUIDIsPermaLink || len(feed.Warnings) != 1 || feed.Warnings[0].Path != "/rss/channel/item[4]/guid" {
+		t.Fatalf("expected a warning for the permalink that is not a URL, got %v", feed.Warnings)
+	}
+
+	if !strings.HasPrefix(items[4].GUID, "urn:fedup:") || items[4].GUIDIsPermaLink {
+		t.Fatalf("expected a synthesized guid, got %q", items[4].GUID)
+	}
+	if items[5].GUID == "" || items[5].GUID == items[4].GUID {
+		t.Fatalf("expected distinct synthesized guids, got %q and %q", items[4].GUID, items[5].GUID)
+	}
+
+	// the same items get the same GUIDs on every parse, and the feed is part of their identity
+	again, err := rss.ParseRSS(strings.NewReader(guidFeed))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+	if again.Items[4].GUID != items[4].GUID || again.Items[5].GUID != items[5].GUID {
+		t.Fatal("synthesized guids are not stable")
+	}
+
+	other, err := rss.ParseRSS(strings.NewReader(strings.Replace(guidFeed, "<link>https://example.com/</link>", "<link>https://example.org/</link>", 1)))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+	if other.Items[5].GUID == items[5].GUID {
+		t.Fatal("expected items of different feeds to get different guids")
+	}
+}
+
+func TestJSONFeedGUID(t *testing.T) {
+	const feed = `{"version": "https://jsonfeed.org/version/1.1", "title": "GUIDs", "home_page_url": "https://example.com/", "items": [
+		{"url": "https://example.com/posts/1", "title": "No id", "date_published": "2002-05-19T15:21:36Z"},
+		{"content_text": "No id, url or title"}
+	]}`
+
+	parsed, err := rss.ParseJSONFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("ParseJSONFeed: %s", err)
+	}
+	items := parsed.Items
+
+	if !strings.HasPrefix(items[0].GUID, "urn:fedup:") || items[1].GUID == "" || items[0].GUID == items[1].GUID {
+		t.Fatalf("expected distinct synthesized guids, got %q and %q", items[0].GUID, items[1].GUID)
+	}
+	if len(parsed.Warnings) != 2 || parsed.Warnings[0].Path != "items[0]" || parsed.Warnings[1].Path != "items[1]" {
+		t.Fatalf("expected a warning for each item without an id, got %v", parsed.Warnings)
+	}
+
+	again, err := rss.ParseJSONFeed(strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("ParseJSONFeed: %s", err)
+	}
+	if again.Items[0].GUID != items[0].GUID || again.Items[1].GUID != items[1].GUID {
+		t.Fatal("synthesized guids are not stable")
+	}
+
+	if _, err := rss.ParseJSONFeedWithOptions(strings.NewReader(feed), &rss.ParseOptions{Strict: true}); err == nil {
+		t.Fatal("expected items without an id to be rejected in strict mode")
+	}
+}
@@ -0,0 +1,128 @@
+package rss
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// The iTunes podcast namespace. See https://help.apple.com/itc/podcasts_connect/#/itcb54353390
+const itunesNS = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+// itunesText returns the trimmed text of the first iTunes element named tag under e
+func itunesText(e *etree.Element, tag string) string {
+	return strings.TrimSpace(selectNS(e, itunesNS, tag).NotNil().Text())
+}
+
+// itunesImage returns the href of the <itunes:image> under e, or nil if there is none, warning if it is malformed
+func itunesImage(e *etree.Element, d *diagnostics) *url.URL {
+	image := selectNS(e, itunesNS, "image")
+	if image == nil {
+		return nil
+	}
+	return attrURL(image, "href", d)
+}
+
+// itunesInt parses the text of the first iTunes element named tag under e as an int, returning 0 if there is none and warning if it is malformed
+func itunesInt(e *etree.Element, tag string, d *diagnostics) int {
+	el := selectNS(e, itunesNS, tag)
+	if el == nil {
+		return 0
+	}
+	return d.atoi(elementPath(el), el.Text())
+}
+
+// parseITunesExplicit interprets the values of <itunes:explicit>. Old feeds use "yes", "explicit" and "clean" instead of "true" and "false"
+func parseITunesExplicit(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "explicit":
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseITunesDuration parses the value of <itunes:duration>, which is either a number of seconds or "HH:MM:SS" or "MM:SS"
+func ParseITunesDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, ":")
+	if s == "" || len(parts) > 3 {
+		return 0, fmt.Errorf("malformed duration %q", s)
+	}
+
+	var seconds float64
+	for i, part := range parts {
+		var v float64
+		var err error
+		if i == len(parts)-1 {
+			// some publishers include fractional seconds
+			v, err = strconv.ParseFloat(part, 64)
+		} else {
+			var n int
+			n, err = strconv.Atoi(part)
+			v = float64(n)
+		}
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("malformed duration %q", s)
+		}
+		seconds = seconds*60 + v
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// hasNS reports whether e has any child element in the namespace uri
+func hasNS(e *etree.Element, uri string) bool {
+	for c := range e.ChildElementsSeq() {
+		if namespaceURI(c) == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// itunesChannel returns the iTunes metadata of a channel, or nil if it has none
+func itunesChannel(e *etree.Element, d *diagnostics) *ITunesFeed {
+	if !hasNS(e, itunesNS) {
+		return nil
+	}
+
+	return &ITunesFeed{
+		Author:   itunesText(e, "author"),
+		Summary:  itunesText(e, "summary"),
+		Image:    itunesImage(e, d),
+		Explicit: parseITunesExplicit(itunesText(e, "explicit")),
+		Type:     itunesText(e, "type"),
+	}
+}
+
+// itunesItem returns the iTunes metadata of an item, or nil if it has none
+func itunesItem(e *etree.Element, d *diagnostics) *ITunesItem {
+	if !hasNS(e, itunesNS) {
+		return nil
+	}
+
+	it := &ITunesItem{
+		Author:      itunesText(e, "author"),
+		Summary:     itunesText(e, "summary"),
+		Image:       itunesImage(e, d),
+		Explicit:    parseITunesExplicit(itunesText(e, "explicit")),
+		EpisodeType: itunesText(e, "episodeType"),
+	}
+
+	if el := selectNS(e, itunesNS, "duration"); el != nil && strings.TrimSpace(el.Text()) != "" {
+		duration, err := ParseITunesDuration(el.Text())
+		if err != nil {
+			d.warn(elementPath(el), SeverityError, "%s", err)
+		}
+		it.Duration = duration
+	}
+	it.Episode = itunesInt(e, "episode", d)
+	it.Season = itunesInt(e, "season", d)
+
+	return it
+}
@@ -0,0 +1,81 @@
+package rss_test
+
+import (
+	_ "embed"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+//go:embed testcase/podcast.xml
+var podcastString string
+
+func TestITunes(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(podcastString))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	if feed.ITunes == nil {
+		t.Fatal("expected iTunes channel metadata, got nil")
+	}
+
+	if feed.ITunes.Author != "The Sunset Explorers" || feed.ITunes.Type != "serial" || feed.ITunes.Explicit {
+		t.Fatalf("unexpected iTunes channel metadata %+v", feed.ITunes)
+	}
+
+	if feed.ITunes.Image == nil || feed.ITunes.Image.Host != "applehosted.podcasts.apple.com" {
+		t.Fatalf("unexpected iTunes image %v", feed.ITunes.Image)
+	}
+
+	trailer, episode := feed.Items[0], feed.Items[1]
+
+	if trailer.Title != "Hiking Treks Trailer" || episode.Title != "S02 EP04 Mt. Hood, Oregon" {
+		t.Fatalf("unexpected item titles %q and %q", trailer.Title, episode.Title)
+	}
+
+	if trailer.ITunes.Duration != 1079*time.Second || trailer.ITunes.EpisodeType != "trailer" {
+		t.Fatalf("unexpected trailer metadata %+v", trailer.ITunes)
+	}
+
+	if episode.Author != "" {
+		t.Fatalf("expected <itunes:author> not to be read as <author>, got %q", episode.Author)
+	}
+
+	expectedDuration := time.Hour + 4*time.Minute + 15*time.Second
+	if episode.ITunes.Duration != expectedDuration {
+		t.Fatalf("expected duration %s, got %s", expectedDuration, episode.ITunes.Duration)
+	}
+
+	if episode.ITunes.Episode != 4 || episode.ITunes.Season != 2 || !episode.ITunes.Explicit || episode.ITunes.Author != "Guest Explorer" {
+		t.Fatalf("unexpected episode metadata %+v", episode.ITunes)
+	}
+}
+
+func TestParseITunesDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"1079":    1079 * time.Second,
+		"17:59":   17*time.Minute + 59*time.Second,
+		"1:02:03": time.Hour + 2*time.Minute + 3*time.Second,
+		"62:03.5": 62*time.Minute + 3500*time.Millisecond,
+		" 00:45 ": 45 * time.Second,
+	}
+
+	for in, expected := range cases {
+		got, err := rss.ParseITunesDuration(in)
+		if err != nil {
+			t.Fatalf("ParseITunesDuration(%q): %s", in, err)
+		}
+		if got != expected {
+			t.Fatalf("ParseITunesDuration(%q): expected %s, got %s", in, expected, got)
+		}
+	}
+
+	for _, in := range []string{"", "1:2:3:4", "an hour", "-5"} {
+		if _, err := rss.ParseITunesDuration(in); err == nil {
+			t.Fatalf("ParseITunesDuration(%q): expected error", in)
+		}
+	}
+}
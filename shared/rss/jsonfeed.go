@@ -0,0 +1,364 @@
+package rss
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/sanitize"
+)
+
+// The version URL prefix shared by JSON Feed 1.0 and 1.1. See https://www.jsonfeed.org/version/1.1/
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/1"
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	NextURL     string         `json:"next_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Language    string         `json:"language,omitempty"`
+	Icon        string         `json:"icon,omitempty"`
+	Favicon     string         `json:"favicon,omitempty"`
+	Author      *jsonAuthor    `json:"author,omitempty"` // deprecated in 1.1 in favour of authors
+	Authors     []*jsonAuthor  `json:"authors,omitempty"`
+	Hubs        []*jsonHub     `json:"hubs,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// jsonHub is an endpoint that pushes updates of the feed, like a WebSub hub
+type jsonHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type jsonAuthor struct {
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            json.RawMessage   `json:"id"`
+	URL           string            `json:"url,omitempty"`
+	ExternalURL   string            `json:"external_url,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	ContentHTML   string            `json:"content_html,omitempty"`
+	ContentText   string            `json:"content_text,omitempty"`
+	Summary       string            `json:"summary,omitempty"`
+	DatePublished string            `json:"date_published,omitempty"`
+	DateModified  string            `json:"date_modified,omitempty"`
+	Author        *jsonAuthor       `json:"author,omitempty"`
+	Authors       []*jsonAuthor     `json:"authors,omitempty"`
+	Attachments   []*jsonAttachment `json:"attachments,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`
+}
+
+type jsonAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	Title       string `json:"title,omitempty"`
+	SizeInBytes int    `json:"size_in_bytes,omitempty"`
+}
+
+// jsonPersons converts the authors of an object, preferring the 1.1 authors array over the 1.0 author object
+// prefix is the path of the object with a trailing ".", like "items[2].", or "" for the feed. Authors without a name are skipped, JSON Feed has no email addresses
+func jsonPersons(authors []*jsonAuthor, author *jsonAuthor, prefix string, d *diagnostics) []*Person {
+	path := func(i int) string { return fmt.Sprintf("%sauthors[%d]", prefix, i) }
+	if len(authors) == 0 && author != nil {
+		authors = []*jsonAuthor{author}
+		path = func(int) string { return prefix + "author" }
+	}
+
+	var persons []*Person
+	for i, a := range authors {
+		if a == nil || (a.Name == "" && a.URL == "") {
+			continue
+		}
+		p := &Person{Name: a.Name, URI: d.parseURL(path(i)+".url", a.URL)}
+		// JSON Feed has no email addresses, but allows a mailto: URL
+		if p.URI != nil && p.URI.Scheme == "mailto" {
+			p.Email, p.URI = p.URI.Opaque, nil
+		}
+		persons = append(persons, p)
+	}
+	return persons
+}
+
+// jsonItemID returns the id of an item as a string. The spec requires a string, but some publishers use numbers
+func jsonItemID(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return "", fmt.Errorf("id is neither a string nor a number (got %s)", raw)
+	}
+	return n.String(), nil
+}
+
+// jsonItemToItem converts the item at path, like "items[2]", to an Item
+func jsonItemToItem(ji *jsonFeedItem, path string, feed *Feed, feedAuthors []*Person, d *diagnostics) (*Item, error) {
+	id, err := jsonItemID(ji.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &Item{
+		Feed:  feed,
+		GUID:  id,
+		Title: ji.Title,
+	}
+
+	// html content is preferred over plain text
+	if ji.ContentHTML != "" {
+		item.Content = ji.ContentHTML
+	} else {
+		item.Content = ji.ContentText
+	}
+
+	// prefer the summary, falling back to the content
+	if ji.Summary != "" {
+		item.Description = ji.Summary
+	} else {
+		item.Description = item.Content
+	}
+
+	if ji.URL != "" {
+		item.Link = d.parseURL(path+".url", ji.URL)
+	} else {
+		item.Link = d.parseURL(path+".external_url", ji.ExternalURL)
+	}
+
+	// items without an author inherit the authors of the feed
+	if item.Authors = jsonPersons(ji.Authors, ji.Author, path+".", d); len(item.Authors) == 0 {
+		item.Authors = feedAuthors
+	}
+	item.Author = personNames(item.Authors)
+
+	if ji.DatePublished != "" {
+		item.PubDate = d.parseDate(path+".date_published", ji.DatePublished)
+	} else {
+		item.PubDate = d.parseDate(path+".date_modified", ji.DateModified)
+	}
+
+	for i, attachment := range ji.Attachments {
+		if attachment == nil {
+			continue
+		}
+		attachmentPath := fmt.Sprintf("%s.attachments[%d]", path, i)
+		u := d.parseURL(attachmentPath+".url", attachment.URL)
+		if u == nil {
+			d.warn(attachmentPath, SeverityError, "attachment has no url")
+			continue
+		}
+		if attachment.MimeType == "" {
+			d.warn(attachmentPath, SeverityWarning, "attachment has no mime_type")
+		}
+		item.Enclosures = append(item.Enclosures, &Enclosure{
+			URL:      u,
+			MimeType: attachment.MimeType,
+			Length:   attachment.SizeInBytes,
+		})
+	}
+
+	for _, tag := range ji.Tags {
+		if tag != "" {
+			item.Categories = append(item.Categories, &Category{Term: tag})
+		}
+	}
+
+	// the id is required, items without one get the same identity on every fetch like RSS items without a <guid>
+	if item.GUID == "" {
+		d.warn(path, SeverityWarning, "item has no id")
+		item.GUID = synthesizeGUID(item)
+	}
+
+	return item, nil
+}
+
+// resolveURLs makes the URLs in jf absolute. They are resolved against the home page, or docBase if it is relative, or the feed_url if there is no docBase
+func (jf *jsonFeed) resolveURLs(docBase *url.URL) {
+	base := docBase
+	if feedURL, err := url.Parse(jf.FeedURL); base == nil && err == nil && feedURL.IsAbs() {
+		base = feedURL
+	}
+	jf.HomePageURL = resolveReference(base, jf.HomePageURL)
+	if home, err := url.Parse(jf.HomePageURL); err == nil && home.IsAbs() {
+		base = home
+	}
+	if base == nil {
+		return
+	}
+
+	jf.NextURL = resolveReference(base, jf.NextURL)
+	for _, hub := range jf.Hubs {
+		if hub != nil {
+			hub.URL = resolveReference(base, hub.URL)
+		}
+	}
+	jf.Icon = resolveReference(base, jf.Icon)
+	jf.Favicon = resolveReference(base, jf.Favicon)
+	for i := range jf.Items {
+		item := &jf.Items[i]
+		item.URL = resolveReference(base, item.URL)
+		item.ExternalURL = resolveReference(base, item.ExternalURL)
+		item.ContentHTML = sanitize.ResolveURLs(item.ContentHTML, base)
+		for _, attachment := range item.Attachments {
+			if attachment != nil {
+				attachment.URL = resolveReference(base, attachment.URL)
+			}
+		}
+	}
+}
+
+// ParseJSONFeed takes a reader with a JSON Feed (version 1.0 or 1.1) and converts it to a lenient Feed object, see ParseJSONFeedWithOptions
+func ParseJSONFeed(r io.Reader) (*Feed, error) {
+	return ParseJSONFeedWithOptions(r, nil)
+}
+
+// ParseJSONFeedWithOptions takes a reader with a JSON Feed (version 1.0 or 1.1) and converts it to a Feed object
+// The summary of an item is preferred over its content for the Description. Attachments become Enclosures and tags become Categories
+func ParseJSONFeedWithOptions(r io.Reader, opts *ParseOptions) (*Feed, error) {
+	var jf jsonFeed
+	if err := json.NewDecoder(r).Decode(&jf); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	if !strings.HasPrefix(jf.Version, jsonFeedVersionPrefix) {
+		return nil, fmt.Errorf("json is not a JSON Feed (version %q)", jf.Version)
+	}
+
+	if jf.Title == "" {
+		return nil, errors.New("feed does not contain title")
+	}
+
+	jf.resolveURLs(opts.baseURL())
+
+	feed := &Feed{
+		Title:       jf.Title,
+		Description: jf.Description,
+		Language:    jf.Language,
+	}
+
+	d := &diagnostics{}
+	feed.Link = d.parseURL("home_page_url", jf.HomePageURL)
+	feed.FetchFrom = d.parseURL("feed_url", jf.FeedURL)
+	for i, hub := range jf.Hubs {
+		if hub != nil && feed.Hub == nil && strings.EqualFold(hub.Type, "WebSub") {
+			feed.Hub = d.parseURL(fmt.Sprintf("hubs[%d].url", i), hub.URL)
+		}
+	}
+	// JSON Feed has a next page, but no other paging links
+	if next := d.parseURL("next_url", jf.NextURL); next != nil {
+		feed.Paging = &Paging{Next: next}
+	}
+
+	// the icon is larger than the favicon
+	icon := d.parseURL("icon", jf.Icon)
+	if icon == nil {
+		icon = d.parseURL("favicon", jf.Favicon)
+	}
+	if icon != nil {
+		feed.Image = &Image{URL: icon, Title: feed.Title, Link: feed.Link}
+	}
+
+	feedAuthors := jsonPersons(jf.Authors, jf.Author, "", d)
+	for i := range jf.Items {
+		path := fmt.Sprintf("items[%d]", i)
+		item, err := jsonItemToItem(&jf.Items[i], path, feed, feedAuthors, d)
+		if err != nil {
+			d.warn(path, SeverityError, "failed to parse item: %s", err)
+			continue
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return d.finish(feed, opts)
+}
+
+// WriteJSONFeed renders feed with its Items as a JSON Feed 1.1 document to w
+// JSON Feed has no place for most of the metadata of RSS, only the fields the parser reads are written
+func WriteJSONFeed(w io.Writer, feed *Feed) error {
+	jf := jsonFeed{
+		Version:     jsonFeedVersionPrefix + ".1",
+		Title:       feed.Title,
+		Description: feed.Description,
+		Language:    feed.Language,
+		Items:       []jsonFeedItem{},
+	}
+	if feed.Link != nil {
+		jf.HomePageURL = feed.Link.String()
+	}
+	if feed.FetchFrom != nil {
+		jf.FeedURL = feed.FetchFrom.String()
+	}
+	if feed.Image != nil && feed.Image.URL != nil {
+		jf.Icon = feed.Image.URL.String()
+	}
+	if feed.Hub != nil {
+		jf.Hubs = []*jsonHub{{Type: "WebSub", URL: feed.Hub.String()}}
+	}
+	if next := feed.Paging.Older(); next != nil {
+		jf.NextURL = next.String()
+	}
+
+	for _, item := range feed.Items {
+		id, err := json.Marshal(item.GUID)
+		if err != nil {
+			return fmt.Errorf("failed to encode id: %w", err)
+		}
+
+		ji := jsonFeedItem{ID: id, Title: item.Title, ContentHTML: item.Content}
+		// the parser takes the Description from the summary, falling back to the content
+		if item.Description != item.Content {
+			ji.Summary = item.Description
+		}
+		if item.Link != nil {
+			ji.URL = item.Link.String()
+		}
+		if item.PubDate != nil {
+			ji.DatePublished = item.PubDate.Format(time.RFC3339)
+		}
+		for _, p := range item.Authors {
+			author := &jsonAuthor{Name: p.Name}
+			switch {
+			case p.URI != nil:
+				author.URL = p.URI.String()
+			case p.Email != "":
+				author.URL = (&url.URL{Scheme: "mailto", Opaque: p.Email}).String()
+			}
+			if author.Name != "" || author.URL != "" {
+				ji.Authors = append(ji.Authors, author)
+			}
+		}
+		if len(item.Authors) == 0 && item.Author != "" {
+			ji.Authors = []*jsonAuthor{{Name: item.Author}}
+		}
+		for _, enc := range item.Enclosures {
+			if enc.URL != nil {
+				ji.Attachments = append(ji.Attachments, &jsonAttachment{URL: enc.URL.String(), MimeType: enc.MimeType, SizeInBytes: enc.Length})
+			}
+		}
+		for _, c := range item.Categories {
+			ji.Tags = append(ji.Tags, c.Term)
+		}
+		jf.Items = append(jf.Items, ji)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(jf)
+}
@@ -0,0 +1,100 @@
+package rss_test
+
+import (
+	_ "embed"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+//go:embed testcase/jsonfeed.json
+var jsonFeedString string
+
+func TestBaseJSONFeed(t *testing.T) {
+	feed, err := rss.ParseJSONFeed(strings.NewReader(jsonFeedString))
+	if err != nil {
+		t.Fatalf("ParseJSONFeed: %s", err)
+	}
+
+	const (
+		expectedTitle           = "My Example Feed"
+		expectedFetchFrom       = "https://example.org/feed.json"
+		expectedItemAuthor      = "Manton Reece, Brent Simmons"
+		expectedItemDescription = "This is a second item."
+		expectedSecondGUID      = "1"
+		expectedSecondAuthor    = "Brent Simmons"
+		expectedEnclosureLength = 89970236
+	)
+
+	if feed.Title != expectedTitle {
+		t.Fatalf("expected feed title %q, got %q", expectedTitle, feed.Title)
+	}
+
+	if feed.FetchFrom.String() != expectedFetchFrom {
+		t.Fatalf("expected feed fetchFrom %q, got %q", expectedFetchFrom, feed.FetchFrom.String())
+	}
+
+	if feed.Image == nil || feed.Image.URL.String() != "https://example.org/icon.png" {
+		t.Fatalf("expected feed image from icon, got %+v", feed.Image)
+	}
+
+	if len(feed.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(feed.Items))
+	}
+
+	first, second := feed.Items[0], feed.Items[1]
+
+	if first.Author != expectedItemAuthor {
+		t.Fatalf("expected item author %q, got %q", expectedItemAuthor, first.Author)
+	}
+
+	if first.Description != expectedItemDescription {
+		t.Fatalf("expected item description %q, got %q", expectedItemDescription, first.Description)
+	}
+
+	if first.PubDate == nil || !first.PubDate.Equal(time.Date(2010, 2, 7, 19, 4, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected item pubDate %v", first.PubDate)
+	}
+
+	if second.GUID != expectedSecondGUID {
+		t.Fatalf("expected numeric id to become guid %q, got %q", expectedSecondGUID, second.GUID)
+	}
+
+	if second.Author != expectedSecondAuthor {
+		t.Fatalf("expected item to inherit feed author %q, got %q", expectedSecondAuthor, second.Author)
+	}
+
+	if len(second.Enclosures) != 2 || second.Enclosures[0].Length != expectedEnclosureLength {
+		t.Fatalf("expected 2 enclosures, the first with length %d, got %+v", expectedEnclosureLength, second.Enclosures)
+	}
+
+	if len(second.Categories) != 2 || second.Categories[1].Term != "podcast" {
+		t.Fatalf("unexpected item categories %+v", second.Categories)
+	}
+}
+
+func TestJSONFeedRejectsOtherJSON(t *testing.T) {
+	if _, err := rss.ParseJSONFeed(strings.NewReader(`{"title": "not a feed"}`)); err == nil {
+		t.Fatal("expected an error parsing JSON without a JSON Feed version")
+	}
+}
+
+func TestJSONFeedHubs(t *testing.T) {
+	const in = `{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "Pushed",
+		"feed_url": "https://example.com/feed.json",
+		"hubs": [{"type": "rssCloud", "url": "https://cloud.example.com/"}, {"type": "WebSub", "url": "/hub"}],
+		"items": []
+	}`
+
+	feed, err := rss.ParseJSONFeed(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseJSONFeed: %s", err)
+	}
+	if feed.Hub == nil || feed.Hub.String() != "https://example.com/hub" {
+		t.Fatalf("expected the WebSub hub, got %v", feed.Hub)
+	}
+}
@@ -0,0 +1,137 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// The Media RSS namespace. See https://www.rssboard.org/media-rss
+const mediaNS = "http://search.yahoo.com/mrss/"
+
+// attrInt returns the attribute key of e as an int, returning 0 if it is missing and warning if it is malformed
+func attrInt(e *etree.Element, key string, d *diagnostics) int {
+	return d.atoi(elementPath(e), e.SelectAttrValue(key, ""))
+}
+
+// mediaThumbnails returns every <media:thumbnail> under e
+func mediaThumbnails(e *etree.Element, d *diagnostics) []*MediaThumbnail {
+	var thumbnails []*MediaThumbnail
+	for el := range childrenNS(e, mediaNS, "thumbnail") {
+		if u := attrURL(el, "url", d); u != nil {
+			thumbnails = append(thumbnails, &MediaThumbnail{
+				URL:    u,
+				Width:  attrInt(el, "width", d),
+				Height: attrInt(el, "height", d),
+			})
+		}
+	}
+	return thumbnails
+}
+
+// mediaContent converts a <media:content> to a MediaContent, or nil if it has no url
+func mediaContent(e *etree.Element, d *diagnostics) *MediaContent {
+	u := attrURL(e, "url", d)
+	if u == nil {
+		return nil
+	}
+
+	var fileSize int64
+	if raw := strings.TrimSpace(e.SelectAttrValue("fileSize", "")); raw != "" {
+		var err error
+		if fileSize, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			d.warn(elementPath(e), SeverityError, "file size %q is not a number", raw)
+		}
+	}
+	// the duration is in seconds, sometimes with a fraction
+	var duration float64
+	if raw := strings.TrimSpace(e.SelectAttrValue("duration", "")); raw != "" {
+		var err error
+		if duration, err = strconv.ParseFloat(raw, 64); err != nil {
+			d.warn(elementPath(e), SeverityError, "duration %q is not a number", raw)
+		}
+	}
+
+	return &MediaContent{
+		URL:       u,
+		MimeType:  e.SelectAttrValue("type", ""),
+		Medium:    e.SelectAttrValue("medium", ""),
+		FileSize:  fileSize,
+		Duration:  time.Duration(duration * float64(time.Second)),
+		Width:     attrInt(e, "width", d),
+		Height:    attrInt(e, "height", d),
+		Bitrate:   attrInt(e, "bitrate", d),
+		Lang:      e.SelectAttrValue("lang", ""),
+		IsDefault: e.SelectAttrValue("isDefault", "") == "true",
+	}
+}
+
+// applyMediaMetadata fills in the title, description and thumbnails of m from the media elements under e, without overwriting existing values
+// The optional elements of Media RSS can appear at the item, group and content level, with the innermost taking precedence
+func applyMediaMetadata(e *etree.Element, m *Media, d *diagnostics) {
+	if m.Title == "" {
+		m.Title = strings.TrimSpace(selectNS(e, mediaNS, "title").NotNil().Text())
+	}
+	if m.Description == "" {
+		m.Description = strings.TrimSpace(selectNS(e, mediaNS, "description").NotNil().Text())
+	}
+	if len(m.Thumbnails) == 0 {
+		m.Thumbnails = mediaThumbnails(e, d)
+	}
+}
+
+// mediaItem returns the media objects of an item
+func mediaItem(e *etree.Element, d *diagnostics) []*Media {
+	if !hasNS(e, mediaNS) {
+		return nil
+	}
+
+	var media []*Media
+	for c := range e.ChildElementsSeq() {
+		if namespaceURI(c) != mediaNS {
+			continue
+		}
+
+		switch c.Tag {
+		case "group":
+			m := &Media{}
+			for contentElem := range childrenNS(c, mediaNS, "content") {
+				if content := mediaContent(contentElem, d); content != nil {
+					m.Contents = append(m.Contents, content)
+				}
+			}
+			applyMediaMetadata(c, m, d)
+			// metadata of the renditions applies to the whole group
+			for contentElem := range childrenNS(c, mediaNS, "content") {
+				applyMediaMetadata(contentElem, m, d)
+			}
+			media = append(media, m)
+		case "content":
+			content := mediaContent(c, d)
+			if content == nil {
+				continue
+			}
+			m := &Media{Contents: []*MediaContent{content}}
+			applyMediaMetadata(c, m, d)
+			media = append(media, m)
+		}
+	}
+
+	// an item can have thumbnails without any content, for example a photo feed linking to a page
+	if len(media) == 0 {
+		m := &Media{}
+		applyMediaMetadata(e, m, d)
+		if m.Title == "" && m.Description == "" && len(m.Thumbnails) == 0 {
+			return nil
+		}
+		return []*Media{m}
+	}
+
+	for _, m := range media {
+		applyMediaMetadata(e, m, d)
+	}
+
+	return media
+}
@@ -0,0 +1,94 @@
+package rss_test
+
+import (
+	_ "embed"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+//go:embed testcase/youtube.xml
+var youtubeString string
+
+const mediaFeed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+   <channel>
+      <title>Photo Gallery</title>
+      <link>https://photos.example.com</link>
+      <description>Videos and photos</description>
+      <item>
+         <title>Sunset timelapse</title>
+         <media:thumbnail url="https://photos.example.com/sunset.jpg" width="320" height="180"/>
+         <media:group>
+            <media:content url="https://photos.example.com/sunset-1080.mp4" type="video/mp4" medium="video" fileSize="104857600" duration="61.5" width="1920" height="1080" bitrate="8000" isDefault="true"/>
+            <media:content url="https://photos.example.com/sunset-480.mp4" type="video/mp4" medium="video" fileSize="10485760" duration="61.5" width="854" height="480" bitrate="1200"/>
+         </media:group>
+         <media:content url="https://photos.example.com/sunset.jpg" medium="image">
+            <media:title>Sunset still</media:title>
+         </media:content>
+      </item>
+      <item>
+         <title>Just a thumbnail</title>
+         <media:thumbnail url="https://photos.example.com/beach.jpg"/>
+      </item>
+   </channel>
+</rss>`
+
+func TestMediaRSS(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(mediaFeed))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	media := feed.Items[0].Media
+	if len(media) != 2 {
+		t.Fatalf("expected 2 media objects, got %d", len(media))
+	}
+
+	video, still := media[0], media[1]
+
+	if len(video.Contents) != 2 {
+		t.Fatalf("expected 2 renditions, got %d", len(video.Contents))
+	}
+
+	hd := video.Contents[0]
+	if !hd.IsDefault || hd.Height != 1080 || hd.FileSize != 104857600 || hd.Duration != 61500*time.Millisecond || hd.Bitrate != 8000 {
+		t.Fatalf("unexpected rendition %+v", hd)
+	}
+
+	// item level thumbnails apply to every media object without its own
+	if len(video.Thumbnails) != 1 || video.Thumbnails[0].Width != 320 {
+		t.Fatalf("expected the item thumbnail on the group, got %+v", video.Thumbnails)
+	}
+
+	if still.Title != "Sunset still" || still.Contents[0].Medium != "image" {
+		t.Fatalf("unexpected media object %+v", still)
+	}
+
+	thumbOnly := feed.Items[1].Media
+	if len(thumbOnly) != 1 || len(thumbOnly[0].Contents) != 0 || len(thumbOnly[0].Thumbnails) != 1 {
+		t.Fatalf("expected a single media object with only a thumbnail, got %+v", thumbOnly)
+	}
+}
+
+func TestMediaYouTube(t *testing.T) {
+	feed, err := rss.ParseAtom(strings.NewReader(youtubeString))
+	if err != nil {
+		t.Fatalf("ParseAtom: %s", err)
+	}
+
+	media := feed.Items[0].Media
+	if len(media) != 1 {
+		t.Fatalf("expected 1 media object, got %d", len(media))
+	}
+
+	if media[0].Title != "I Built a PC Inside a PC" || !strings.HasPrefix(media[0].Description, "Thanks") {
+		t.Fatalf("unexpected media metadata %+v", media[0])
+	}
+
+	if len(media[0].Thumbnails) != 1 || media[0].Thumbnails[0].Height != 360 {
+		t.Fatalf("unexpected thumbnails %+v", media[0].Thumbnails)
+	}
+}
@@ -0,0 +1,97 @@
+package rss
+
+import (
+	"net/url"
+
+	"github.com/beevik/etree"
+)
+
+// The Feed History namespace of RFC 5005, for <fh:complete> and <fh:archive>. See https://www.rfc-editor.org/rfc/rfc5005
+const fhNS = "http://purl.org/syndication/history/1.0"
+
+// Paging represents the links of a feed document to the other documents of the same feed, as described by RFC 5005
+type Paging struct {
+	// The pages of a paged feed (section 3). Servers usually put older entries on the Next page
+	First    *url.URL
+	Last     *url.URL
+	Previous *url.URL
+	Next     *url.URL
+	// The documents before and after this one in an archived feed (section 4), and the subscription document with the newest entries
+	PrevArchive *url.URL
+	NextArchive *url.URL
+	Current     *url.URL
+	// Whether the document has every entry of the feed, from <fh:complete>. The other documents of a complete feed are ignored
+	Complete bool
+	// Whether the document is an archive document, from <fh:archive>
+	Archive bool
+}
+
+// Older returns the URL of the document with the entries that come before those of this one: the previous archive document of an archived feed,
+// or the next page of a paged feed. Returns nil if there is none or the feed is complete
+func (p *Paging) Older() *url.URL {
+	if p == nil || p.Complete {
+		return nil
+	}
+	if p.PrevArchive != nil {
+		return p.PrevArchive
+	}
+	return p.Next
+}
+
+// links returns the link relations of RFC 5005 with the fields of p they are stored in. "prev" is a synonym of "previous"
+func (p *Paging) links() map[string]**url.URL {
+	return map[string]**url.URL{
+		"first":        &p.First,
+		"last":         &p.Last,
+		"previous":     &p.Previous,
+		"prev":         &p.Previous,
+		"next":         &p.Next,
+		"prev-archive": &p.PrevArchive,
+		"next-archive": &p.NextArchive,
+		"current":      &p.Current,
+	}
+}
+
+// atomPaging returns the paging links and markers of the Atom <feed> or the RSS <channel> e, or nil if it has none
+func atomPaging(e *etree.Element, d *diagnostics) *Paging {
+	p := &Paging{
+		Complete: selectNS(e, fhNS, "complete") != nil,
+		Archive:  selectNS(e, fhNS, "archive") != nil,
+	}
+
+	links := p.links()
+	for link := range childrenNS(e, atomNS, "link") {
+		if dst, ok := links[link.SelectAttrValue("rel", "")]; ok && *dst == nil {
+			*dst = d.parseURL(elementPath(link), link.SelectAttrValue("href", ""))
+		}
+	}
+
+	if *p == (Paging{}) {
+		return nil
+	}
+	return p
+}
+
+// writePaging adds the links and markers of p to e. tag is the name of the link elements, "link" in Atom and "atom:link" in RSS
+func (x *xmlWriter) writePaging(e *etree.Element, p *Paging, tag string) {
+	if p == nil {
+		return
+	}
+
+	if p.Complete {
+		x.element(e, "fh:complete")
+	}
+	if p.Archive {
+		x.element(e, "fh:archive")
+	}
+
+	// in a fixed order, and without the "prev" synonym
+	links := p.links()
+	for _, rel := range []string{"first", "last", "previous", "next", "prev-archive", "next-archive", "current"} {
+		if href := *links[rel]; href != nil {
+			link := x.element(e, tag)
+			link.CreateAttr("href", href.String())
+			link.CreateAttr("rel", rel)
+		}
+	}
+}
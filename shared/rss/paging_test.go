@@ -0,0 +1,133 @@
+package rss_test
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+const archiveAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:fh="http://purl.org/syndication/history/1.0">
+   <title>Archive</title>
+   <id>urn:uuid:60a76c80-d399-11d9-b93C-0003939e0af6</id>
+   <updated>2003-12-13T18:30:02Z</updated>
+   <fh:archive/>
+   <link rel="current" href="https://example.org/index.atom"/>
+   <link rel="self" href="https://example.org/2003/11/index.atom"/>
+   <link rel="prev-archive" href="https://example.org/2003/10/index.atom"/>
+   <link rel="next-archive" href="https://example.org/2003/12/index.atom"/>
+   <entry>
+      <title>Archived</title>
+      <id>urn:uuid:1</id>
+      <updated>2003-11-13T18:30:02Z</updated>
+   </entry>
+</feed>`
+
+const pagedRSS = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+   <channel>
+      <title>Paged</title>
+      <link>https://example.com/</link>
+      <description>Paged</description>
+      <atom:link rel="first" href="/feed"/>
+      <atom:link rel="next" href="/feed?page=3"/>
+      <atom:link rel="prev" href="/feed?page=1"/>
+      <item><title>Page 2</title></item>
+   </channel>
+</rss>`
+
+func TestPagingArchive(t *testing.T) {
+	feed, err := rss.ParseAtom(strings.NewReader(archiveAtom))
+	if err != nil {
+		t.Fatalf("ParseAtom: %s", err)
+	}
+
+	p := feed.Paging
+	if p == nil || !p.Archive || p.Complete || p.Current.String() != "https://example.org/index.atom" || p.NextArchive == nil {
+		t.Fatalf("unexpected paging %+v", p)
+	}
+	if p.Older().String() != "https://example.org/2003/10/index.atom" {
+		t.Fatalf("expected the previous archive to be older, got %s", p.Older())
+	}
+	if feed.Extensions != nil {
+		t.Fatalf("expected <fh:archive> not to be an extension, got %v", feed.Extensions)
+	}
+
+	// a complete feed has nothing older
+	complete := strings.Replace(archiveAtom, "<fh:archive/>", "<fh:complete/>", 1)
+	feed, err = rss.ParseAtom(strings.NewReader(complete))
+	if err != nil {
+		t.Fatalf("ParseAtom: %s", err)
+	}
+	if !feed.Paging.Complete || feed.Paging.Older() != nil {
+		t.Fatalf("expected a complete feed, got %+v", feed.Paging)
+	}
+}
+
+func TestPagingRSS(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(pagedRSS))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	p := feed.Paging
+	if p == nil || p.First.String() != "https://example.com/feed" || p.Previous.String() != "https://example.com/feed?page=1" {
+		t.Fatalf("unexpected paging %+v", p)
+	}
+	if p.Older().String() != "https://example.com/feed?page=3" {
+		t.Fatalf("expected the next page to be older, got %s", p.Older())
+	}
+
+	// the links survive writing
+	for _, format := range []rss.Format{rss.FormatRSS, rss.FormatAtom} {
+		var buf bytes.Buffer
+		if err := rss.Write(&buf, feed, format); err != nil {
+			t.Fatalf("Write %s: %s", format, err)
+		}
+		got, err := rss.Parse(&buf, "")
+		if err != nil {
+			t.Fatalf("Parse %s: %s", format, err)
+		}
+		if got.Paging == nil || *got.Paging.Next != *p.Next || *got.Paging.Previous != *p.Previous {
+			t.Fatalf("%s: expected paging %+v, got %+v", format, p, got.Paging)
+		}
+	}
+
+	// with the URL of the document, the links are relative to it rather than the website
+	base, _ := url.Parse("https://feeds.example.net/blog/rss")
+	feed, err = rss.ParseWithOptions(strings.NewReader(pagedRSS), "", &rss.ParseOptions{BaseURL: base})
+	if err != nil {
+		t.Fatalf("ParseWithOptions: %s", err)
+	}
+	if feed.Paging.Next.String() != "https://feeds.example.net/feed?page=3" || feed.Link.String() != "https://example.com/" {
+		t.Fatalf("unexpected next page %s and link %s", feed.Paging.Next, feed.Link)
+	}
+
+	var none *rss.Paging
+	if none.Older() != nil {
+		t.Fatal("expected nothing older without paging")
+	}
+}
+
+func TestPagingJSONFeed(t *testing.T) {
+	const in = `{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "Paged",
+		"feed_url": "https://example.com/feed.json",
+		"next_url": "feed.json?page=2",
+		"items": []
+	}`
+
+	feed, err := rss.ParseJSONFeed(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseJSONFeed: %s", err)
+	}
+
+	expected, _ := url.Parse("https://example.com/feed.json?page=2")
+	if feed.Paging.Older().String() != expected.String() {
+		t.Fatalf("expected next page %s, got %+v", expected, feed.Paging)
+	}
+}
@@ -0,0 +1,174 @@
+package rss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+)
+
+// Format is a feed document format understood by Parse
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatRSS            // RSS 0.9x and 2.0, <rss> root
+	FormatAtom           // Atom 1.0, <feed> root
+	FormatRDF            // RSS 0.90 and 1.0, <rdf:RDF> root
+	FormatJSON           // JSON Feed 1.0 and 1.1
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatRSS:
+		return "RSS"
+	case FormatAtom:
+		return "Atom"
+	case FormatRDF:
+		return "RDF"
+	case FormatJSON:
+		return "JSON Feed"
+	default:
+		return "unknown"
+	}
+}
+
+// How many bytes at the start of a document are inspected to find its root
+const sniffLen = 4096
+
+// UnknownFormatError is returned by Parse when neither the document nor its Content-Type match a supported format
+type UnknownFormatError struct {
+	// The Content-Type the document was served with, if any
+	ContentType string
+	// The name of the root element for XML documents, empty if the document is not XML or the root could not be found
+	Root string
+}
+
+func (e *UnknownFormatError) Error() string {
+	switch {
+	case e.Root != "":
+		return fmt.Sprintf("unknown feed format: unsupported root element <%s> (Content-Type %q)", e.Root, e.ContentType)
+	default:
+		return fmt.Sprintf("unknown feed format (Content-Type %q)", e.ContentType)
+	}
+}
+
+// passthroughCharsetReader lets the decoders read past an encoding declaration. Documents are converted to UTF-8 by utf8Reader before they are decoded
+func passthroughCharsetReader(_ string, input io.Reader) (io.Reader, error) {
+	return input, nil
+}
+
+// sniffFormat inspects the start of a document and returns its format, along with the name of the root element for XML
+func sniffFormat(head []byte) (Format, string) {
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+	head = bytes.TrimLeft(head, " \t\r\n")
+	if len(head) == 0 {
+		return FormatUnknown, ""
+	}
+
+	if head[0] == '{' {
+		return FormatJSON, ""
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(head))
+	d.CharsetReader = passthroughCharsetReader
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return FormatUnknown, ""
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case start.Name.Local == "rss":
+			return FormatRSS, start.Name.Local
+		case start.Name.Local == "feed" && start.Name.Space == atomNS:
+			return FormatAtom, start.Name.Local
+		case start.Name.Local == "RDF" && start.Name.Space == rdfNS:
+			return FormatRDF, start.Name.Local
+		default:
+			return FormatUnknown, start.Name.Local
+		}
+	}
+}
+
+// formatFromContentType maps a Content-Type header to a format, or FormatUnknown for generic types like text/xml
+func formatFromContentType(contentType string) Format {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return FormatUnknown
+	}
+
+	switch mediaType {
+	case "application/rss+xml":
+		return FormatRSS
+	case "application/atom+xml":
+		return FormatAtom
+	case "application/rdf+xml":
+		return FormatRDF
+	case "application/feed+json", "application/json":
+		return FormatJSON
+	default:
+		return FormatUnknown
+	}
+}
+
+// DetectFormat determines the format of the document in r. The document root is authoritative, the contentType (which may be empty) is only consulted when the root cannot be recognized
+// The returned reader must be used in place of r, as the start of the document has been consumed from r. It has the document converted to UTF-8,
+// from the charset given by a byte order mark, the charset parameter of contentType or the XML declaration
+func DetectFormat(r io.Reader, contentType string) (Format, io.Reader, error) {
+	r, err := utf8Reader(r, contentType)
+	if err != nil {
+		return FormatUnknown, r, err
+	}
+
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return FormatUnknown, br, err
+	}
+
+	format, root := sniffFormat(head)
+	if format == FormatUnknown && root == "" {
+		format = formatFromContentType(contentType)
+	}
+	if format == FormatUnknown {
+		return FormatUnknown, br, &UnknownFormatError{ContentType: contentType, Root: root}
+	}
+
+	return format, br, nil
+}
+
+// Parse takes a reader with a feed in any supported format and converts it to a Feed object
+// The format is detected from the document root (<rss>, <feed>, <rdf:RDF> or a JSON object), falling back to the contentType, which may be empty
+// An *UnknownFormatError is returned if the format cannot be determined
+func Parse(r io.Reader, contentType string) (*Feed, error) {
+	return ParseWithOptions(r, contentType, nil)
+}
+
+// ParseWithOptions is Parse with ParseOptions, see ParseRSSWithOptions
+func ParseWithOptions(r io.Reader, contentType string, opts *ParseOptions) (*Feed, error) {
+	format, r, err := DetectFormat(r, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatRSS:
+		return parseXML(r, rssLayout(), opts)
+	case FormatAtom:
+		return parseXML(r, atomLayout(), opts)
+	case FormatRDF:
+		return parseXML(r, rdfLayout(), opts)
+	case FormatJSON:
+		return ParseJSONFeedWithOptions(r, opts)
+	default:
+		return nil, &UnknownFormatError{ContentType: contentType}
+	}
+}
@@ -0,0 +1,49 @@
+package rss_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+func TestParseDetectsFormat(t *testing.T) {
+	cases := []struct {
+		name          string
+		doc           string
+		contentType   string
+		expectedTitle string
+	}{
+		{"rss", rss2String, "text/xml", "NASA Space Station News"},
+		{"atom", atomString, "", "dive into mark"},
+		{"rdf", rdfString, "application/rss+xml", "XML.com"},
+		{"json", jsonFeedString, "text/plain", "My Example Feed"},
+		{"bom", "\xef\xbb\xbf" + atomString, "", "dive into mark"},
+	}
+
+	for _, c := range cases {
+		feed, err := rss.Parse(strings.NewReader(c.doc), c.contentType)
+		if err != nil {
+			t.Fatalf("%s: Parse: %s", c.name, err)
+		}
+		if feed.Title != c.expectedTitle {
+			t.Fatalf("%s: expected feed title %q, got %q", c.name, c.expectedTitle, feed.Title)
+		}
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	const doc = `<!DOCTYPE html><html><head><title>Not a feed</title></head></html>`
+
+	_, err := rss.Parse(strings.NewReader(doc), "text/html; charset=utf-8")
+
+	var formatErr *rss.UnknownFormatError
+	if !errors.As(err, &formatErr) {
+		t.Fatalf("expected *UnknownFormatError, got %v", err)
+	}
+
+	if formatErr.Root != "html" {
+		t.Fatalf("expected root %q, got %q", "html", formatErr.Root)
+	}
+}
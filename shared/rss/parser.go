@@ -4,140 +4,311 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"iter"
 	"net/url"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/beevik/etree"
 )
 
-func channelElementToFeed(e *etree.Element) (*Feed, error) {
+// The RSS content module namespace, used for <content:encoded>. See https://web.resource.org/rss/1.0/modules/content/
+const contentNS = "http://purl.org/rss/1.0/modules/content/"
+
+// namespaceAliases maps the other URIs that feeds declare for a namespace to the one the parser reads
+var namespaceAliases = map[string]string{
+	podcastDocsNS: podcastNS,
+}
+
+// namespaceURI returns the namespace URI of e, with an alias replaced by the URI the parser reads
+func namespaceURI(e *etree.Element) string {
+	uri := e.NamespaceURI()
+	if canonical, ok := namespaceAliases[uri]; ok {
+		return canonical
+	}
+	return uri
+}
+
+// childrenNS yields the child elements of e with the local name tag in the namespace uri
+func childrenNS(e *etree.Element, uri, tag string) iter.Seq[*etree.Element] {
+	return func(yield func(*etree.Element) bool) {
+		for c := range e.ChildElementsSeq() {
+			if c.Tag == tag && namespaceURI(c) == uri {
+				if !yield(c) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// selectNS returns the first child element of e with the local name tag in the namespace uri, or nil if there is none
+func selectNS(e *etree.Element, uri, tag string) *etree.Element {
+	for c := range childrenNS(e, uri, tag) {
+		return c
+	}
+	return nil
+}
+
+// attrURL parses the attribute key of e as a URL, returning nil if it is missing and warning if it is malformed
+func attrURL(e *etree.Element, key string, d *diagnostics) *url.URL {
+	return d.parseURL(elementPath(e), e.SelectAttrValue(key, ""))
+}
+
+// rssSelect returns the first child element of e named tag that is not in a namespace, or nil if there is none
+// Unlike e.SelectElement, it does not match namespaced elements with the same local name, like <itunes:title>
+func rssSelect(e *etree.Element, tag string) *etree.Element {
+	return selectNS(e, "", tag)
+}
+
+// rssText returns the trimmed text of the first child element of e named tag that is not in a namespace
+func rssText(e *etree.Element, tag string) string {
+	return strings.TrimSpace(rssSelect(e, tag).NotNil().Text())
+}
+
+// rssURL parses the text of the first child element of e named tag as a URL, returning nil if there is none and warning if it is malformed
+func rssURL(e *etree.Element, tag string, d *diagnostics) *url.URL {
+	el := rssSelect(e, tag)
+	if el == nil {
+		return nil
+	}
+	return d.parseURL(elementPath(el), el.Text())
+}
+
+// rssInt parses the text of the first child element of e named tag as an int, returning 0 if there is none and warning if it is malformed
+func rssInt(e *etree.Element, tag string, d *diagnostics) int {
+	el := rssSelect(e, tag)
+	if el == nil {
+		return 0
+	}
+	return d.atoi(elementPath(el), el.Text())
+}
+
+// rssImage returns the <image> of a channel, or nil if it has none or it has no url
+func rssImage(e *etree.Element, d *diagnostics) *Image {
+	el := rssSelect(e, "image")
+	if el == nil {
+		return nil
+	}
+
+	u := rssURL(el, "url", d)
+	if u == nil {
+		d.warn(elementPath(el), SeverityError, "<image> does not contain <url>")
+		return nil
+	}
+
+	return &Image{
+		URL:         u,
+		Title:       rssText(el, "title"),
+		Link:        rssURL(el, "link", d),
+		Width:       rssInt(el, "width", d),
+		Height:      rssInt(el, "height", d),
+		Description: rssText(el, "description"),
+	}
+}
+
+// rssTextInput returns the <textInput> of a channel, or nil if it has none
+func rssTextInput(e *etree.Element, d *diagnostics) *TextInput {
+	el := rssSelect(e, "textInput")
+	if el == nil {
+		return nil
+	}
+
+	return &TextInput{
+		Title:       rssText(el, "title"),
+		Description: rssText(el, "description"),
+		Name:        rssText(el, "name"),
+		Link:        rssURL(el, "link", d),
+	}
+}
+
+// rssCloud returns the <cloud> of a channel, or nil if it has none or it has no domain
+func rssCloud(e *etree.Element, d *diagnostics) *Cloud {
+	el := rssSelect(e, "cloud")
+	if el == nil {
+		return nil
+	}
+
+	domain := strings.TrimSpace(el.SelectAttrValue("domain", ""))
+	if domain == "" {
+		d.warn(elementPath(el), SeverityError, "<cloud> does not have a domain")
+		return nil
+	}
+
+	return &Cloud{
+		Domain:            domain,
+		Port:              d.atoi(elementPath(el), el.SelectAttrValue("port", "")),
+		Path:              el.SelectAttrValue("path", ""),
+		RegisterProcedure: el.SelectAttrValue("registerProcedure", ""),
+		Protocol:          el.SelectAttrValue("protocol", ""),
+	}
+}
+
+// rssCategories returns every <category> under e
+func rssCategories(e *etree.Element) []*Category {
+	var categories []*Category
+	for el := range childrenNS(e, "", "category") {
+		if term := strings.TrimSpace(el.Text()); term != "" {
+			categories = append(categories, &Category{Term: term, Domain: el.SelectAttrValue("domain", "")})
+		}
+	}
+	return categories
+}
+
+func channelElementToFeed(e *etree.Element, d *diagnostics) (*Feed, error) {
 	if !strings.Contains(e.Tag, "channel") {
 		return nil, errors.New("element is not a <channel>")
 	}
 
 	feed := &Feed{}
+	path := elementPath(e)
 
-	if title := e.SelectElement("title"); title == nil {
+	if title := rssSelect(e, "title"); title == nil {
 		return nil, errors.New("<channel> does not contain <title>")
 	} else {
 		feed.Title = title.Text()
 	}
 
-	if description := e.SelectElement("description"); description == nil {
+	if description := rssSelect(e, "description"); description == nil {
 		return nil, errors.New("<channel> does not contain <description>")
 	} else {
 		feed.Description = description.Text()
 	}
 
-	if link := e.SelectElement("link"); link == nil {
+	if link := rssSelect(e, "link"); link == nil {
 		return nil, errors.New("<channel> does not contain <link>")
 	} else {
-		parsedUrl, err := url.Parse(link.Text())
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse url: %w", err)
-		}
-		feed.Link = parsedUrl
+		feed.Link = d.parseURL(elementPath(link), link.Text())
 	}
 
+	atomFeedLinks(e, feed, d)
+
 	// spec violation: allow any value for <language>
-	if language := e.SelectElement("language"); language != nil {
+	if language := rssSelect(e, "language"); language != nil {
 		feed.Language = language.Text()
 	}
 
-	if ttl := e.SelectElement("ttl"); ttl != nil {
-		parsedTTL, err := strconv.Atoi(ttl.Text())
-		if err != nil {
-			return nil, fmt.Errorf("malformed feed: ttl is not a number (got %s)", ttl.Text())
-		}
-		feed.TTL = parsedTTL
-	}
+	feed.TTL = rssInt(e, "ttl", d)
+	feed.SkipHours = rssSkipHours(e, d)
+	feed.SkipDays = rssSkipDays(e, d)
+	feed.Copyright = rssText(e, "copyright")
+	feed.ManagingEditor = rssText(e, "managingEditor")
+	feed.WebMaster = rssText(e, "webMaster")
+	feed.PubDate = d.parseDate(path+"/pubDate", rssText(e, "pubDate"))
+	feed.LastBuildDate = d.parseDate(path+"/lastBuildDate", rssText(e, "lastBuildDate"))
+	feed.Generator = rssText(e, "generator")
+	feed.Docs = rssURL(e, "docs", d)
+	feed.Cloud = rssCloud(e, d)
+	feed.Rating = rssText(e, "rating")
+	feed.Image = rssImage(e, d)
+	feed.TextInput = rssTextInput(e, d)
+	feed.Categories = rssCategories(e)
 
-	return feed, nil
-}
+	applyDCChannel(e, feed)
+	feed.ITunes = itunesChannel(e, d)
+	feed.Podcast = podcastChannel(e, d)
 
-func parseRSSDate(dateStr string) (time.Time, error) {
-	layouts := []string{
-		time.RFC1123Z, // "Mon, 02 Jan 2006 15:04:05 -0700"
-		time.RFC1123,  // "Mon, 02 Jan 2006 15:04:05 MST"
-		time.RFC822Z,  // "02 Jan 06 15:04 -0700"
-		time.RFC822,   // "02 Jan 06 15:04 MST"
-	}
-
-	var lastErr error
-	for _, layout := range layouts {
-		if t, err := time.Parse(layout, dateStr); err == nil {
-			return t, nil
-		} else {
-			lastErr = err
-		}
-	}
-	return time.Time{}, fmt.Errorf("could not parse RSS date %q: %w", dateStr, lastErr)
+	return feed, nil
 }
 
-func itemElementToItem(e *etree.Element, feed *Feed) (*Item, error) {
+func itemElementToItem(e *etree.Element, feed *Feed, d *diagnostics) (*Item, error) {
 	if !strings.Contains(e.Tag, "item") {
 		return nil, errors.New("element is not an <item>")
 	}
 
-	// TODO: add error handling
+	path := elementPath(e)
 	item := &Item{
 		Feed:        feed,
-		GUID:        e.SelectElement("guid").NotNil().Text(),
-		Title:       e.SelectElement("title").NotNil().Text(),
-		Description: e.SelectElement("description").NotNil().Text(),
-		Link:        func() *url.URL { url, _ := url.Parse(e.SelectElement("link").NotNil().Text()); return url }(),
-		Author:      e.SelectElement("author").NotNil().Text(),
-		PubDate:     func() *time.Time { date, _ := parseRSSDate(e.SelectElement("pubDate").NotNil().Text()); return &date }(),
-	}
-
-	var enclosure *Enclosure
-	if enclosureElem := e.SelectElement("enclosure"); enclosureElem != nil {
-		// TODO: fix spec violation: allowing nullable enclosure elements
-		enclosure = &Enclosure{
-			URL:      func() *url.URL { url, _ := url.Parse(enclosureElem.SelectAttrValue("url", "")); return url }(),
-			MimeType: enclosureElem.SelectAttrValue("type", ""),
-			Length:   func() int { i, _ := strconv.Atoi(enclosureElem.SelectAttrValue("length", "0")); return i }(),
-		}
+		Title:       rssSelect(e, "title").NotNil().Text(),
+		Description: rssSelect(e, "description").NotNil().Text(),
+		Content:     selectNS(e, contentNS, "encoded").NotNil().Text(),
+		Link:        rssURL(e, "link", d),
+		Author:      rssSelect(e, "author").NotNil().Text(),
+		PubDate:     d.parseDate(path+"/pubDate", rssText(e, "pubDate")),
 	}
-	item.Enclosure = enclosure
 
-	return item, nil
-}
+	rssGUID(e, item, d)
+	item.Authors = parsePersons(childrenNS(e, "", "author"))
 
-// ParseRSS takes a reader with RSS XML and converts it to a Feed object
-// This parser is not fully up to spec: it allows enclosure subelements to be null; allows both title and description of an item to be null
-func ParseRSS(r io.Reader) (*Feed, error) {
-	doc := etree.NewDocument()
-	if _, err := doc.ReadFrom(r); err != nil {
-		return nil, err
+	// spec violation: allow items without both a title and a description
+	if rssSelect(e, "title") == nil && rssSelect(e, "description") == nil {
+		d.warn(path, SeverityWarning, "<item> contains neither <title> nor <description>")
 	}
 
-	root := doc.SelectElement("rss")
-	if root == nil {
-		return nil, errors.New("xml does not have <rss> tag")
-	}
+	// the spec allows a single enclosure per item, but podcasts with several formats are common
+	for enclosureElem := range childrenNS(e, "", "enclosure") {
+		enclosurePath := elementPath(enclosureElem)
+		u := d.parseURL(enclosurePath, enclosureElem.SelectAttrValue("url", ""))
+		if u == nil {
+			d.warn(enclosurePath, SeverityError, "<enclosure> has no url")
+			continue
+		}
 
-	channel := root.SelectElement("channel")
-	if channel == nil {
-		return nil, errors.New("xml does not have <channel> tag")
+		// spec violation: allow enclosures without a type or length, they are only needed to decide whether to download it
+		enclosure := &Enclosure{URL: u, MimeType: enclosureElem.SelectAttrValue("type", "")}
+		if enclosure.MimeType == "" {
+			d.warn(enclosurePath, SeverityWarning, "<enclosure> has no type")
+		}
+		if length := enclosureElem.SelectAttr("length"); length == nil {
+			d.warn(enclosurePath, SeverityWarning, "<enclosure> has no length")
+		} else {
+			enclosure.Length = d.atoi(enclosurePath, length.Value)
+		}
+		item.Enclosures = append(item.Enclosures, enclosure)
 	}
 
-	feed, err := channelElementToFeed(channel)
+	item.Categories = rssCategories(e)
+
+	applyDCItem(e, item, d)
+	item.ITunes = itunesItem(e, d)
+	item.Podcast = podcastItem(e, d)
+	item.Media = mediaItem(e, d)
+
+	return item, nil
+}
+
+// ParseRSS takes a reader with RSS XML and converts it to a lenient Feed object, see ParseRSSWithOptions
+func ParseRSS(r io.Reader) (*Feed, error) {
+	return ParseRSSWithOptions(r, nil)
+}
+
+// ParseRSSWithOptions takes a reader with RSS XML and converts it to a Feed object
+// In lenient mode, the parser is not fully up to spec: it allows enclosures without a type or length, and items without both a title and a description.
+// These and malformed values are reported in Feed.Warnings
+// Documents in other charsets than UTF-8 are converted, according to their byte order mark or XML declaration
+func ParseRSSWithOptions(r io.Reader, opts *ParseOptions) (*Feed, error) {
+	r, err := utf8Reader(r, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse <channel> element: %w", err)
+		return nil, err
 	}
+	return parseXML(r, rssLayout(), opts)
+}
 
-	for itemElem := range channel.SelectElementsSeq("item") {
-		item, err := itemElementToItem(itemElem, feed)
-		if err != nil {
-			log.Printf("failed to parse <item> element in feed %q: %s", feed.Title, err)
-			continue
-		}
-		feed.Items = append(feed.Items, item)
+// rssLayout finds the <item> elements in the <channel> of an <rss> document
+func rssLayout() *xmlLayout {
+	return &xmlLayout{
+		root: func(e *etree.Element) error {
+			if e.Tag != "rss" {
+				return errors.New("xml does not have <rss> tag")
+			}
+			return nil
+		},
+		container: func(e *etree.Element) bool {
+			return e.Tag == "channel" && isRoot(e.Parent())
+		},
+		noContainer: errors.New("xml does not have <channel> tag"),
+		isItem: func(e *etree.Element) bool {
+			return e.Tag == "item"
+		},
+		link: func(channel *etree.Element) *etree.Element {
+			return rssSelect(channel, "link")
+		},
+		feed: func(channel *etree.Element, d *diagnostics) (*Feed, error) {
+			feed, err := channelElementToFeed(channel, d)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse <channel> element: %w", err)
+			}
+			return feed, nil
+		},
+		item: itemElementToItem,
 	}
-
-	return feed, nil
 }
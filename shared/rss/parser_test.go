@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/its-mrarsikk/fedup/shared/rss"
 )
@@ -11,11 +12,18 @@ import (
 //go:embed testcase/rss2.xml
 var rss2String string
 
-func TestBaseRSS2(t *testing.T) {
+// parseRSS2 parses the RSS 2.0 fixture
+func parseRSS2(t *testing.T) *rss.Feed {
+	t.Helper()
 	feed, err := rss.ParseRSS(strings.NewReader(rss2String))
 	if err != nil {
 		t.Fatalf("ParseRSS: %s", err)
 	}
+	return feed
+}
+
+func TestBaseRSS2(t *testing.T) {
+	feed := parseRSS2(t)
 
 	const (
 		expectedTitle           = "NASA Space Station News"
@@ -31,7 +39,78 @@ func TestBaseRSS2(t *testing.T) {
 		t.Fatalf("expected item title %s, got %s", feed.Items[0].Title, expectedItemTitle)
 	}
 
-	if feed.Items[2].Enclosure.Length != expectedEnclosureLength {
-		t.Fatalf("expected enclosure length %d, got %d", expectedEnclosureLength, feed.Items[2].Enclosure.Length)
+	if feed.Items[2].Enclosures[0].Length != expectedEnclosureLength {
+		t.Fatalf("expected enclosure length %d, got %d", expectedEnclosureLength, feed.Items[2].Enclosures[0].Length)
+	}
+}
+
+func TestRSSContent(t *testing.T) {
+	feed := parseRSS2(t)
+
+	if !strings.HasPrefix(feed.Items[0].Content, "<p>") {
+		t.Fatalf("expected item content from <content:encoded>, got %q", feed.Items[0].Content)
+	}
+
+	if feed.Items[1].Content != "" {
+		t.Fatalf("expected no item content, got %q", feed.Items[1].Content)
+	}
+}
+
+func TestRSSDates(t *testing.T) {
+	feed := parseRSS2(t)
+
+	// the fixture uses dates without seconds and with a named timezone
+	expectedPubDate := time.Date(2023, 7, 21, 13, 4, 0, 0, time.UTC)
+	if feed.Items[0].PubDate == nil || !feed.Items[0].PubDate.Equal(expectedPubDate) {
+		t.Fatalf("expected item pubDate %s, got %v", expectedPubDate, feed.Items[0].PubDate)
+	}
+
+	if feed.PubDate == nil || !feed.PubDate.Equal(time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected channel pubDate %v", feed.PubDate)
+	}
+
+	if feed.LastBuildDate == nil || !feed.LastBuildDate.Equal(expectedPubDate) {
+		t.Fatalf("unexpected channel lastBuildDate %v", feed.LastBuildDate)
+	}
+}
+
+func TestRSSCategories(t *testing.T) {
+	feed := parseRSS2(t)
+
+	if len(feed.Categories) != 1 || feed.Categories[0].Term != "Science" {
+		t.Fatalf("unexpected channel categories %+v", feed.Categories)
+	}
+
+	categories := feed.Items[0].Categories
+	if len(categories) != 2 || categories[1].Term != "Education" || categories[1].Domain != "https://www.nasa.gov/topics" {
+		t.Fatalf("unexpected item categories %+v", categories)
+	}
+}
+
+func TestRSSChannel(t *testing.T) {
+	feed := parseRSS2(t)
+
+	if feed.Copyright != "Public domain" || feed.Generator != "Blosxom 2.1.2" || feed.Docs.String() != "https://www.rssboard.org/rss-specification" {
+		t.Fatalf("unexpected channel metadata %q, %q, %v", feed.Copyright, feed.Generator, feed.Docs)
+	}
+
+	if !strings.HasPrefix(feed.ManagingEditor, "neil.armstrong@example.com") || !strings.HasPrefix(feed.WebMaster, "sally.ride@example.com") {
+		t.Fatalf("unexpected managingEditor %q or webMaster %q", feed.ManagingEditor, feed.WebMaster)
+	}
+
+	if feed.Image == nil || feed.Image.URL.String() != "https://www.nasa.gov/images/nasa-logo.png" || feed.Image.Width != 144 || feed.Image.Link.String() != "http://www.nasa.gov/" {
+		t.Fatalf("unexpected channel image %+v", feed.Image)
+	}
+
+	if feed.TextInput == nil || feed.TextInput.Name != "q" || feed.TextInput.Link.String() != "https://www.nasa.gov/search" {
+		t.Fatalf("unexpected channel textInput %+v", feed.TextInput)
+	}
+}
+
+func TestRSSCloud(t *testing.T) {
+	feed := parseRSS2(t)
+
+	if feed.Cloud == nil || feed.Cloud.Domain != "rpc.nasa.gov" || feed.Cloud.Port != 80 || feed.Cloud.Path != "/rsscloud/pleaseNotify" || feed.Cloud.Protocol != "http-post" {
+		t.Fatalf("unexpected channel cloud %+v", feed.Cloud)
 	}
 }
@@ -0,0 +1,70 @@
+package rss
+
+import (
+	"iter"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// The RSS form of a person, an email address followed by a name in parentheses
+var rssPersonRegexp = regexp.MustCompile(`^(?:mailto:)?(\S+@\S+)\s*\((.*)\)$`)
+
+// ParsePerson parses the RSS form of a person, an email address usually followed by a name in parentheses, like "joe@example.com (Joe Bloggs)".
+// The "Joe Bloggs <joe@example.com>" form, a bare email address and a bare name are accepted too. Returns nil if s is empty
+func ParsePerson(s string) *Person {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+
+	if m := rssPersonRegexp.FindStringSubmatch(s); m != nil {
+		return &Person{Name: strings.TrimSpace(m[2]), Email: m[1]}
+	}
+	if addr, err := mail.ParseAddress(strings.TrimPrefix(s, "mailto:")); err == nil {
+		return &Person{Name: addr.Name, Email: addr.Address}
+	}
+	return &Person{Name: s}
+}
+
+// String returns the name of p, or its email address if it has no name
+func (p *Person) String() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Email
+}
+
+// rssString returns p in the form ParsePerson reads
+func (p *Person) rssString() string {
+	switch {
+	case p.Email == "":
+		return p.Name
+	case p.Name == "":
+		return p.Email
+	default:
+		return p.Email + " (" + p.Name + ")"
+	}
+}
+
+// personNames joins the names of persons with ", "
+func personNames(persons []*Person) string {
+	names := make([]string, 0, len(persons))
+	for _, p := range persons {
+		names = append(names, p.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// parsePersons parses the text of every element in seq with ParsePerson
+func parsePersons(seq iter.Seq[*etree.Element]) []*Person {
+	var persons []*Person
+	for el := range seq {
+		if p := ParsePerson(el.Text()); p != nil {
+			persons = append(persons, p)
+		}
+	}
+	return persons
+}
@@ -0,0 +1,122 @@
+package rss_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+func TestParsePerson(t *testing.T) {
+	tests := map[string]rss.Person{
+		"joe@example.com (Joe Bloggs)":        {Name: "Joe Bloggs", Email: "joe@example.com"},
+		"mailto:joe@example.com (Joe Bloggs)": {Name: "Joe Bloggs", Email: "joe@example.com"},
+		"Joe Bloggs <joe@example.com>":        {Name: "Joe Bloggs", Email: "joe@example.com"},
+		"  joe@example.com ":                  {Email: "joe@example.com"},
+		"Joe Bloggs":                          {Name: "Joe Bloggs"},
+		"Joe (the editor)":                    {Name: "Joe (the editor)"},
+	}
+
+	for in, expected := range tests {
+		got := rss.ParsePerson(in)
+		if got == nil || got.Name != expected.Name || got.Email != expected.Email {
+			t.Errorf("ParsePerson(%q): expected %+v, got %+v", in, expected, got)
+		}
+	}
+
+	if got := rss.ParsePerson(" "); got != nil {
+		t.Fatalf("expected nil for an empty person, got %+v", got)
+	}
+}
+
+func TestAuthorsRSS(t *testing.T) {
+	const in = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+   <channel>
+      <title>Authors</title>
+      <link>https://example.com</link>
+      <description>Authors</description>
+      <item>
+         <title>Native</title>
+         <author>joe@example.com (Joe Bloggs)</author>
+      </item>
+      <item>
+         <title>Dublin Core</title>
+         <dc:creator>Alice</dc:creator>
+         <dc:creator>Bob</dc:creator>
+      </item>
+   </channel>
+</rss>`
+
+	feed, err := rss.ParseRSS(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	first, second := feed.Items[0], feed.Items[1]
+	if len(first.Authors) != 1 || first.Authors[0].Name != "Joe Bloggs" || first.Authors[0].Email != "joe@example.com" {
+		t.Fatalf("unexpected authors %+v", first.Authors)
+	}
+	if len(second.Authors) != 2 || second.Authors[1].Name != "Bob" || second.Author != "Alice, Bob" {
+		t.Fatalf("unexpected authors %+v (%q)", second.Authors, second.Author)
+	}
+}
+
+func TestAuthorsAtom(t *testing.T) {
+	const in = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+   <title>Authors</title>
+   <id>urn:uuid:60a76c80-d399-11d9-b93C-0003939e0af6</id>
+   <updated>2003-12-13T18:30:02Z</updated>
+   <author>
+      <name>Feed Author</name>
+   </author>
+   <entry>
+      <title>Two authors</title>
+      <id>urn:uuid:1</id>
+      <updated>2003-12-13T18:30:02Z</updated>
+      <author>
+         <name>Mark Pilgrim</name>
+         <email>f8dy@example.com</email>
+         <uri>http://example.org/</uri>
+      </author>
+      <author>
+         <name>Sam Ruby</name>
+      </author>
+   </entry>
+   <entry>
+      <title>Inherited</title>
+      <id>urn:uuid:2</id>
+      <updated>2003-12-13T18:30:02Z</updated>
+   </entry>
+</feed>`
+
+	feed, err := rss.ParseAtom(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseAtom: %s", err)
+	}
+
+	first, second := feed.Items[0], feed.Items[1]
+	mark := first.Authors[0]
+	if len(first.Authors) != 2 || mark.Email != "f8dy@example.com" || mark.URI.String() != "http://example.org/" || first.Author != "Mark Pilgrim, Sam Ruby" {
+		t.Fatalf("unexpected authors %+v (%q)", first.Authors, first.Author)
+	}
+	if len(second.Authors) != 1 || second.Authors[0].Name != "Feed Author" {
+		t.Fatalf("expected the author of the feed, got %+v", second.Authors)
+	}
+}
+
+func TestAuthorsJSONFeed(t *testing.T) {
+	feed, err := rss.ParseJSONFeed(strings.NewReader(jsonFeedString))
+	if err != nil {
+		t.Fatalf("ParseJSONFeed: %s", err)
+	}
+
+	first, second := feed.Items[0], feed.Items[1]
+	if len(first.Authors) != 2 || first.Authors[0].Name != "Manton Reece" || first.Authors[0].URI != nil {
+		t.Fatalf("unexpected authors %+v", first.Authors)
+	}
+	if len(second.Authors) != 1 || second.Authors[0].URI.String() != "https://example.org/brent" {
+		t.Fatalf("expected the author of the feed with a url, got %+v", second.Authors)
+	}
+}
@@ -0,0 +1,95 @@
+package rss
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// The Podcasting 2.0 namespace. See https://podcastindex.org/namespace/1.0
+const podcastNS = "https://podcastindex.org/namespace/1.0"
+
+// The URI of the namespace documentation, which many podcast hosts declare instead of podcastNS
+const podcastDocsNS = "https://github.com/Podcastindex-org/podcast-namespace/blob/main/docs/1.0.md"
+
+// podcastPersons returns every <podcast:person> under e
+func podcastPersons(e *etree.Element, d *diagnostics) []*PodcastPerson {
+	var persons []*PodcastPerson
+	for el := range childrenNS(e, podcastNS, "person") {
+		persons = append(persons, &PodcastPerson{
+			Name: strings.TrimSpace(el.Text()),
+			// the role and group default to a host of the cast
+			Role:  strings.ToLower(el.SelectAttrValue("role", "host")),
+			Group: strings.ToLower(el.SelectAttrValue("group", "cast")),
+			Image: attrURL(el, "img", d),
+			Href:  attrURL(el, "href", d),
+		})
+	}
+	return persons
+}
+
+// podcastChannel returns the Podcasting 2.0 metadata of a channel, or nil if it has none
+func podcastChannel(e *etree.Element, d *diagnostics) *PodcastFeed {
+	if !hasNS(e, podcastNS) {
+		return nil
+	}
+
+	p := &PodcastFeed{
+		GUID:    strings.TrimSpace(selectNS(e, podcastNS, "guid").NotNil().Text()),
+		Persons: podcastPersons(e, d),
+	}
+
+	if locked := selectNS(e, podcastNS, "locked"); locked != nil {
+		p.Locked = strings.EqualFold(strings.TrimSpace(locked.Text()), "yes")
+		p.LockedOwner = locked.SelectAttrValue("owner", "")
+	}
+
+	for el := range childrenNS(e, podcastNS, "funding") {
+		if u := attrURL(el, "url", d); u != nil {
+			p.Funding = append(p.Funding, &PodcastFunding{URL: u, Text: strings.TrimSpace(el.Text())})
+		}
+	}
+
+	return p
+}
+
+// podcastItem returns the Podcasting 2.0 metadata of an item, or nil if it has none
+func podcastItem(e *etree.Element, d *diagnostics) *PodcastItem {
+	if !hasNS(e, podcastNS) {
+		return nil
+	}
+
+	p := &PodcastItem{Persons: podcastPersons(e, d)}
+
+	for el := range childrenNS(e, podcastNS, "transcript") {
+		if u := attrURL(el, "url", d); u != nil {
+			p.Transcripts = append(p.Transcripts, &PodcastTranscript{
+				URL:      u,
+				MimeType: el.SelectAttrValue("type", ""),
+				Language: el.SelectAttrValue("language", ""),
+				Rel:      el.SelectAttrValue("rel", ""),
+			})
+		}
+	}
+
+	if el := selectNS(e, podcastNS, "chapters"); el != nil {
+		if u := attrURL(el, "url", d); u != nil {
+			p.Chapters = &PodcastChapters{URL: u, MimeType: el.SelectAttrValue("type", "")}
+		}
+	}
+
+	return p
+}
+
+// Files returns the URLs of the transcripts and chapters file of an episode, for fetching them as Attachments
+func (p *PodcastItem) Files() []*url.URL {
+	var urls []*url.URL
+	for _, t := range p.Transcripts {
+		urls = append(urls, t.URL)
+	}
+	if p.Chapters != nil {
+		urls = append(urls, p.Chapters.URL)
+	}
+	return urls
+}
@@ -0,0 +1,81 @@
+package rss_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+func TestPodcastNamespace(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(podcastString))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	p := feed.Podcast
+	if p == nil {
+		t.Fatal("expected Podcasting 2.0 channel metadata, got nil")
+	}
+
+	if p.GUID != "917393e3-1b1e-5cef-ace4-edaa54e1f810" || !p.Locked || p.LockedOwner != "john@example.com" {
+		t.Fatalf("unexpected channel metadata %+v", p)
+	}
+
+	if len(p.Funding) != 1 || p.Funding[0].Text != "Support the show!" {
+		t.Fatalf("unexpected funding %+v", p.Funding)
+	}
+
+	if len(p.Persons) != 1 || p.Persons[0].Name != "John Appleseed" || p.Persons[0].Role != "host" {
+		t.Fatalf("unexpected channel persons %+v", p.Persons)
+	}
+
+	if feed.Items[0].Podcast != nil {
+		t.Fatalf("expected no Podcasting 2.0 metadata on the trailer, got %+v", feed.Items[0].Podcast)
+	}
+
+	episode := feed.Items[1].Podcast
+	if episode == nil {
+		t.Fatal("expected Podcasting 2.0 episode metadata, got nil")
+	}
+
+	if len(episode.Transcripts) != 2 || episode.Transcripts[1].Rel != "captions" || episode.Transcripts[1].Language != "en" {
+		t.Fatalf("unexpected transcripts %+v", episode.Transcripts)
+	}
+
+	if episode.Chapters == nil || episode.Chapters.MimeType != "application/json+chapters" {
+		t.Fatalf("unexpected chapters %+v", episode.Chapters)
+	}
+
+	if len(episode.Persons) != 1 || episode.Persons[0].Role != "guest" {
+		t.Fatalf("unexpected episode persons %+v", episode.Persons)
+	}
+
+	if files := episode.Files(); len(files) != 3 || files[2].String() != "https://example.com/episode4/chapters.json" {
+		t.Fatalf("unexpected files %v", files)
+	}
+
+	// <podcast:guid> must not be read as the <guid> of the channel or items
+	if feed.Items[1].GUID != "22BCFEBF-44FB-4A19-8A6A-D3AF6F6A6B2E" {
+		t.Fatalf("unexpected item guid %q", feed.Items[1].GUID)
+	}
+}
+
+func TestPodcastDocsNamespace(t *testing.T) {
+	// many hosts declare the URL of the namespace documentation instead of the namespace
+	doc := strings.ReplaceAll(podcastString, "https://podcastindex.org/namespace/1.0", "https://github.com/Podcastindex-org/podcast-namespace/blob/main/docs/1.0.md")
+	feed, err := rss.ParseRSSWithOptions(strings.NewReader(doc), &rss.ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("ParseRSSWithOptions: %s", err)
+	}
+
+	if feed.Podcast == nil || feed.Podcast.GUID != "917393e3-1b1e-5cef-ace4-edaa54e1f810" || len(feed.Podcast.Persons) != 1 {
+		t.Fatalf("unexpected channel metadata %+v", feed.Podcast)
+	}
+	if episode := feed.Items[1].Podcast; episode == nil || len(episode.Transcripts) != 2 || episode.Chapters == nil {
+		t.Fatalf("unexpected episode metadata %+v", episode)
+	}
+	if feed.Extensions != nil || feed.Items[1].Extensions != nil {
+		t.Fatalf("expected no unknown extensions, got %v and %v", feed.Extensions, feed.Items[1].Extensions)
+	}
+}
@@ -0,0 +1,153 @@
+package rss
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+const (
+	rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	// The namespace of RSS 1.0 elements. See https://web.resource.org/rss/1.0/spec
+	rss1NS = "http://purl.org/rss/1.0/"
+	// The namespace of RSS 0.90 elements, which share the RDF layout of RSS 1.0
+	rss090NS = "http://my.netscape.com/rdf/simple/0.9/"
+)
+
+// rdfAbout returns the rdf:about attribute of e, whatever prefix the document binds the RDF namespace to
+func rdfAbout(e *etree.Element) string {
+	for _, a := range e.Attr {
+		if a.Key == "about" && a.NamespaceURI() == rdfNS {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func rdfChannelElementToFeed(e *etree.Element, ns string, d *diagnostics) (*Feed, error) {
+	if e.Tag != "channel" {
+		return nil, errors.New("element is not a <channel>")
+	}
+
+	feed := &Feed{}
+
+	if title := selectNS(e, ns, "title"); title == nil {
+		return nil, errors.New("<channel> does not contain <title>")
+	} else {
+		feed.Title = strings.TrimSpace(title.Text())
+	}
+
+	// spec violation: RSS 0.90 and many RSS 1.0 feeds in the wild omit <description>
+	feed.Description = strings.TrimSpace(selectNS(e, ns, "description").NotNil().Text())
+
+	if link := selectNS(e, ns, "link"); link == nil {
+		return nil, errors.New("<channel> does not contain <link>")
+	} else {
+		feed.Link = d.parseURL(elementPath(link), link.Text())
+	}
+
+	applyDCChannel(e, feed)
+
+	return feed, nil
+}
+
+func rdfItemElementToItem(e *etree.Element, ns string, feed *Feed, d *diagnostics) (*Item, error) {
+	if e.Tag != "item" {
+		return nil, errors.New("element is not an <item>")
+	}
+
+	item := &Item{
+		Feed:        feed,
+		Title:       strings.TrimSpace(selectNS(e, ns, "title").NotNil().Text()),
+		Description: strings.TrimSpace(selectNS(e, ns, "description").NotNil().Text()),
+		Content:     strings.TrimSpace(selectNS(e, contentNS, "encoded").NotNil().Text()),
+	}
+
+	// RSS 1.0 has no <guid>, the rdf:about URI identifies the item instead
+	item.GUID = strings.TrimSpace(selectNS(e, dcNS, "identifier").NotNil().Text())
+	if item.GUID == "" {
+		item.GUID = rdfAbout(e)
+	}
+
+	if link := selectNS(e, ns, "link"); link != nil {
+		item.Link = d.parseURL(elementPath(link), link.Text())
+	}
+
+	applyDCItem(e, item, d)
+
+	return item, nil
+}
+
+// ParseRDF takes a reader with RSS 1.0 (or RSS 0.90) XML and converts it to a lenient Feed object, see ParseRDFWithOptions
+func ParseRDF(r io.Reader) (*Feed, error) {
+	return ParseRDFWithOptions(r, nil)
+}
+
+// ParseRDFWithOptions takes a reader with RSS 1.0 (or RSS 0.90) XML and converts it to a Feed object
+// Unlike RSS 2.0, the <item> elements are siblings of the <channel> under the <rdf:RDF> root
+func ParseRDFWithOptions(r io.Reader, opts *ParseOptions) (*Feed, error) {
+	r, err := utf8Reader(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return parseXML(r, rdfLayout(), opts)
+}
+
+// rdfChannel returns the <channel> under the root of an RSS 1.0 or 0.90 document, with the namespace of its version
+func rdfChannel(root *etree.Element) (*etree.Element, string) {
+	if channel := selectNS(root, rss1NS, "channel"); channel != nil {
+		return channel, rss1NS
+	}
+	return selectNS(root, rss090NS, "channel"), rss090NS
+}
+
+// rdfLayout finds the <item> elements next to the <channel> in the <rdf:RDF> root of an RSS 1.0 or 0.90 document
+func rdfLayout() *xmlLayout {
+	// the namespace of the RSS elements, RSS 1.0 or RSS 0.90
+	var ns string
+
+	return &xmlLayout{
+		root: func(e *etree.Element) error {
+			if e.Tag != "RDF" || e.NamespaceURI() != rdfNS {
+				return errors.New("xml does not have <rdf:RDF> tag")
+			}
+			return nil
+		},
+		container:   isRoot,
+		noContainer: errors.New("xml does not have <rdf:RDF> tag"),
+		isItem: func(e *etree.Element) bool {
+			uri := e.NamespaceURI()
+			return e.Tag == "item" && (uri == rss1NS || uri == rss090NS)
+		},
+		link: func(root *etree.Element) *etree.Element {
+			channel, ns := rdfChannel(root)
+			if channel == nil {
+				return nil
+			}
+			return selectNS(channel, ns, "link")
+		},
+		channel: func(root *etree.Element) *etree.Element {
+			channel, _ := rdfChannel(root)
+			return channel
+		},
+		feed: func(root *etree.Element, d *diagnostics) (*Feed, error) {
+			var channel *etree.Element
+			channel, ns = rdfChannel(root)
+			if channel == nil {
+				return nil, errors.New("xml does not have <channel> tag")
+			}
+
+			feed, err := rdfChannelElementToFeed(channel, ns, d)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse <channel> element: %w", err)
+			}
+			return feed, nil
+		},
+		item: func(e *etree.Element, feed *Feed, d *diagnostics) (*Item, error) {
+			return rdfItemElementToItem(e, ns, feed, d)
+		},
+	}
+}
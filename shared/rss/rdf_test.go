@@ -0,0 +1,58 @@
+package rss_test
+
+import (
+	_ "embed"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+//go:embed testcase/rdf.xml
+var rdfString string
+
+func TestBaseRDF(t *testing.T) {
+	feed, err := rss.ParseRDF(strings.NewReader(rdfString))
+	if err != nil {
+		t.Fatalf("ParseRDF: %s", err)
+	}
+
+	const (
+		expectedTitle      = "XML.com"
+		expectedLanguage   = "en-us"
+		expectedItemGUID   = "http://xml.com/pub/2000/08/09/xslt/xslt.html"
+		expectedItemAuthor = "Bob DuCharme"
+	)
+
+	if feed.Title != expectedTitle {
+		t.Fatalf("expected feed title %q, got %q", expectedTitle, feed.Title)
+	}
+
+	if feed.Language != expectedLanguage {
+		t.Fatalf("expected feed language %q, got %q", expectedLanguage, feed.Language)
+	}
+
+	if len(feed.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(feed.Items))
+	}
+
+	first, second := feed.Items[0], feed.Items[1]
+
+	if first.GUID != expectedItemGUID {
+		t.Fatalf("expected item guid %q from rdf:about, got %q", expectedItemGUID, first.GUID)
+	}
+
+	if first.Author != expectedItemAuthor {
+		t.Fatalf("expected item author %q, got %q", expectedItemAuthor, first.Author)
+	}
+
+	expectedDate := time.Date(2000, 8, 9, 17, 30, 0, 0, time.UTC)
+	if first.PubDate == nil || !first.PubDate.Equal(expectedDate) {
+		t.Fatalf("expected item pubDate %s, got %v", expectedDate, first.PubDate)
+	}
+
+	if second.PubDate == nil || !second.PubDate.Equal(time.Date(2000, 8, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected date-only pubDate, got %v", second.PubDate)
+	}
+}
@@ -0,0 +1,134 @@
+package rss
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/its-mrarsikk/fedup/shared/sanitize"
+)
+
+// Attributes that hold a URL on any element, like the url of <enclosure> and the href of <atom:link>
+var urlAttrs = []string{"url", "href", "src", "img"}
+
+// Elements whose text is a URL
+var urlElements = []string{"link", "url", "docs", "comments", "logo", "icon", "uri"}
+
+// Elements whose text may be escaped HTML, unless their type says otherwise
+var htmlElements = []string{"description", "encoded", "content", "summary", "subtitle"}
+
+// resolveReference returns raw resolved against base, or raw itself if there is no base or raw is empty, absolute or malformed
+func resolveReference(base *url.URL, raw string) string {
+	if base == nil || strings.TrimSpace(raw) == "" {
+		return raw
+	}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.IsAbs() {
+		return raw
+	}
+	return base.ResolveReference(u).String()
+}
+
+// withBase returns base with the xml:base attribute of e, if it has one, applied
+func withBase(e *etree.Element, base *url.URL) *url.URL {
+	attr := e.SelectAttr("xml:base")
+	if attr == nil {
+		return base
+	}
+	u, err := url.Parse(resolveReference(base, attr.Value))
+	if err != nil {
+		return base
+	}
+	return u
+}
+
+// xmlBase returns the base URL of e: fallback with the xml:base attributes of the ancestors of e and e itself applied
+func xmlBase(e *etree.Element, fallback *url.URL) *url.URL {
+	var elements []*etree.Element
+	for el := e; el != nil; el = el.Parent() {
+		elements = append(elements, el)
+	}
+
+	base := fallback
+	for _, el := range slices.Backward(elements) {
+		base = withBase(el, base)
+	}
+	return base
+}
+
+// resolveTree makes the URLs in e and its descendants absolute, where parentBase is the base URL of the parent of e.
+// URLs are in the attributes in urlAttrs, the text of the elements in urlElements, and the attributes of HTML in the elements in htmlElements.
+// The text of extension elements is kept as it is, their names may mean something else in their namespace
+func resolveTree(e *etree.Element, parentBase *url.URL) {
+	base := withBase(e, parentBase)
+
+	for i, a := range e.Attr {
+		if a.Space == "" && slices.Contains(urlAttrs, a.Key) {
+			e.Attr[i].Value = resolveReference(base, a.Value)
+		}
+	}
+
+	children := e.ChildElements()
+	switch {
+	case len(children) > 0:
+		// xhtml content, whose elements are resolved like any other
+	case !builtinNamespaces[namespaceURI(e)]:
+	case slices.Contains(urlElements, e.Tag):
+		if text, resolved := e.Text(), resolveReference(base, e.Text()); resolved != text {
+			e.SetText(resolved)
+		}
+	case slices.Contains(htmlElements, e.Tag):
+		// Atom text constructs are plain text unless their type says otherwise
+		defaultType := "html"
+		if e.NamespaceURI() == atomNS {
+			defaultType = "text"
+		}
+		switch e.SelectAttrValue("type", defaultType) {
+		case "text", "plain":
+		default:
+			if text, resolved := e.Text(), sanitize.ResolveURLs(e.Text(), base); resolved != text {
+				e.SetText(resolved)
+			}
+		}
+	}
+
+	for _, c := range children {
+		resolveTree(c, base)
+	}
+}
+
+// resolveChannel makes the URLs in the elements of the container that are not items absolute, and returns the URL the items are resolved against:
+// the link of the channel, or docBase if it has no absolute link. xml:base takes precedence over both
+func resolveChannel(container *etree.Element, layout *xmlLayout, docBase *url.URL) *url.URL {
+	fallback := docBase
+	if link := layout.link(container); link != nil {
+		base := xmlBase(link, docBase)
+		if href := link.SelectAttr("href"); href != nil {
+			href.Value = resolveReference(base, href.Value)
+		} else {
+			link.SetText(resolveReference(base, link.Text()))
+		}
+
+		if u, err := url.Parse(strings.TrimSpace(link.SelectAttrValue("href", link.Text()))); err == nil && u.IsAbs() {
+			fallback = u
+		}
+	}
+
+	base := xmlBase(container, fallback)
+	// links to the other documents of the feed, like rel="self" and the paging links, are relative to the document rather than the website
+	docLinkBase := base
+	if docBase != nil {
+		docLinkBase = xmlBase(container, docBase)
+	}
+	for _, c := range container.ChildElements() {
+		switch {
+		case layout.isItem(c):
+		case c.Tag == "link" && c.NamespaceURI() == atomNS:
+			resolveTree(c, docLinkBase)
+		default:
+			resolveTree(c, base)
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,148 @@
+package rss_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+const relativeRSS = `<?xml version="1.0"?>
+<rss version="2.0">
+   <channel>
+      <title>Relative</title>
+      <link>https://example.com/blog/</link>
+      <description>Relative URLs</description>
+      <image>
+         <url>/logo.png</url>
+         <title>Relative</title>
+         <link>./</link>
+      </image>
+      <item>
+         <title>Linked from the channel</title>
+         <link>posts/1</link>
+         <description><![CDATA[<p><img src="img/1.png" srcset="img/1.png 1x, img/1@2x.png 2x" alt="one"> <a href="https://other.example/">away</a></p>]]></description>
+         <enclosure url="media/1.mp3" type="audio/mpeg" length="1"/>
+      </item>
+      <item xml:base="https://cdn.example.com/feeds/">
+         <title>With xml:base</title>
+         <link>posts/2</link>
+         <enclosure url="../media/2.mp3" type="audio/mpeg" length="1"/>
+      </item>
+   </channel>
+</rss>`
+
+func TestResolveRSS(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(relativeRSS))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	expected := map[string]string{
+		"image url":          "https://example.com/logo.png",
+		"image link":         "https://example.com/blog/",
+		"item link":          "https://example.com/blog/posts/1",
+		"enclosure":          "https://example.com/blog/media/1.mp3",
+		"xml:base item link": "https://cdn.example.com/feeds/posts/2",
+		"xml:base enclosure": "https://cdn.example.com/media/2.mp3",
+	}
+	got := map[string]string{
+		"image url":          feed.Image.URL.String(),
+		"image link":         feed.Image.Link.String(),
+		"item link":          feed.Items[0].Link.String(),
+		"enclosure":          feed.Items[0].Enclosures[0].URL.String(),
+		"xml:base item link": feed.Items[1].Link.String(),
+		"xml:base enclosure": feed.Items[1].Enclosures[0].URL.String(),
+	}
+	for name, u := range expected {
+		if got[name] != u {
+			t.Errorf("expected %s %q, got %q", name, u, got[name])
+		}
+	}
+
+	const description = `<p><img src="https://example.com/blog/img/1.png" srcset="https://example.com/blog/img/1.png 1x, https://example.com/blog/img/1@2x.png 2x" alt="one"> <a href="https://other.example/">away</a></p>`
+	if feed.Items[0].Description != description {
+		t.Fatalf("expected description %q, got %q", description, feed.Items[0].Description)
+	}
+}
+
+func TestResolveBaseURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/feeds/main.xml")
+	in := strings.Replace(relativeRSS, "<link>https://example.com/blog/</link>", "<link>/blog/</link>", 1)
+
+	// the link of the channel is relative, so it is resolved against the BaseURL, and then used itself
+	feed, err := rss.ParseWithOptions(strings.NewReader(in), "", &rss.ParseOptions{BaseURL: base})
+	if err != nil {
+		t.Fatalf("ParseWithOptions: %s", err)
+	}
+
+	if feed.Link.String() != "https://example.com/blog/" || feed.Items[0].Link.String() != "https://example.com/blog/posts/1" {
+		t.Fatalf("unexpected links %q and %q", feed.Link, feed.Items[0].Link)
+	}
+
+	// without a BaseURL, relative URLs stay relative
+	feed, err = rss.ParseRSS(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+	if feed.Items[0].Link.String() != "posts/1" {
+		t.Fatalf("expected a relative link, got %q", feed.Items[0].Link)
+	}
+}
+
+func TestResolveAtom(t *testing.T) {
+	const in = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:base="https://example.org/site/">
+   <title>Relative Atom</title>
+   <link href="/"/>
+   <id>urn:uuid:60a76c80-d399-11d9-b93C-0003939e0af6</id>
+   <updated>2003-12-13T18:30:02Z</updated>
+   <entry xml:base="2003/">
+      <title>Entry</title>
+      <link href="12/13/atom03"/>
+      <link rel="enclosure" type="audio/mpeg" length="1" href="atom03.mp3"/>
+      <id>urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a</id>
+      <updated>2003-12-13T18:30:02Z</updated>
+      <summary>A &lt;a href="plain"&gt; text summary</summary>
+      <content type="html" xml:base="https://img.example.org/">&lt;img src="x.png"&gt;</content>
+   </entry>
+</feed>`
+
+	feed, err := rss.ParseAtom(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseAtom: %s", err)
+	}
+
+	item := feed.Items[0]
+	if feed.Link.String() != "https://example.org/" || item.Link.String() != "https://example.org/site/2003/12/13/atom03" {
+		t.Fatalf("unexpected links %q and %q", feed.Link, item.Link)
+	}
+	if item.Enclosures[0].URL.String() != "https://example.org/site/2003/atom03.mp3" {
+		t.Fatalf("unexpected enclosure %q", item.Enclosures[0].URL)
+	}
+	if item.Description != `A <a href="plain"> text summary` || item.Content != `<img src="https://img.example.org/x.png">` {
+		t.Fatalf("unexpected description %q and content %q", item.Description, item.Content)
+	}
+}
+
+func TestResolveJSONFeed(t *testing.T) {
+	const in = `{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "Relative",
+		"home_page_url": "/",
+		"feed_url": "https://example.com/feed.json",
+		"items": [{"id": "1", "url": "posts/1", "content_html": "<img src=\"a.png\">", "attachments": [{"url": "a.mp3", "mime_type": "audio/mpeg"}]}]
+	}`
+
+	feed, err := rss.ParseJSONFeed(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseJSONFeed: %s", err)
+	}
+
+	item := feed.Items[0]
+	if feed.Link.String() != "https://example.com/" || item.Link.String() != "https://example.com/posts/1" ||
+		item.Enclosures[0].URL.String() != "https://example.com/a.mp3" || item.Content != `<img src="https://example.com/a.png">` {
+		t.Fatalf("unexpected URLs %q, %q, %q and content %q", feed.Link, item.Link, item.Enclosures[0].URL, item.Content)
+	}
+}
@@ -0,0 +1,76 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// rssSkipHours returns the hours in the <skipHours> of a channel, ignoring (and warning about) values outside of 0-23
+// Some feeds use 24 for midnight, which is accepted as 0
+func rssSkipHours(e *etree.Element, d *diagnostics) []int {
+	skip := rssSelect(e, "skipHours")
+	if skip == nil {
+		return nil
+	}
+
+	var hours []int
+	for el := range childrenNS(skip, "", "hour") {
+		hour, err := strconv.Atoi(strings.TrimSpace(el.Text()))
+		if err != nil || hour < 0 || hour > 24 {
+			d.warn(elementPath(el), SeverityError, "%q is not an hour", el.Text())
+			continue
+		}
+		hours = append(hours, hour%24)
+	}
+	return hours
+}
+
+// rssSkipDays returns the days in the <skipDays> of a channel, ignoring (and warning about) unknown day names
+func rssSkipDays(e *etree.Element, d *diagnostics) []time.Weekday {
+	skip := rssSelect(e, "skipDays")
+	if skip == nil {
+		return nil
+	}
+
+	var days []time.Weekday
+	for el := range childrenNS(skip, "", "day") {
+		name := strings.TrimSpace(el.Text())
+		found := false
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(name, day.String()) {
+				days = append(days, day)
+				found = true
+				break
+			}
+		}
+		if !found {
+			d.warn(elementPath(el), SeverityError, "%q is not a day", name)
+		}
+	}
+	return days
+}
+
+// SkipsAt reports whether t falls in one of hours or days, as found in Feed.SkipHours and Feed.SkipDays
+// The spec defines both in GMT, so t is converted to UTC first
+func SkipsAt(t time.Time, hours []int, days []time.Weekday) bool {
+	t = t.UTC()
+	for _, h := range hours {
+		if t.Hour() == h {
+			return true
+		}
+	}
+	for _, d := range days {
+		if t.Weekday() == d {
+			return true
+		}
+	}
+	return false
+}
+
+// Skips reports whether the reader should not refresh the feed at t, according to its SkipHours and SkipDays
+func (f *Feed) Skips(t time.Time) bool {
+	return SkipsAt(t, f.SkipHours, f.SkipDays)
+}
@@ -0,0 +1,62 @@
+package rss_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+const skipFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+   <channel>
+      <title>Office hours</title>
+      <link>https://example.com/</link>
+      <description>Only updated on weekdays during the day</description>
+      <skipHours>
+         <hour>0</hour>
+         <hour>1</hour>
+         <hour>24</hour>
+         <hour>twelve</hour>
+      </skipHours>
+      <skipDays>
+         <day>Saturday</day>
+         <day>sunday</day>
+         <day>Caturday</day>
+      </skipDays>
+   </channel>
+</rss>`
+
+func TestSkipHoursAndDays(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(skipFeed))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	if len(feed.SkipHours) != 3 || feed.SkipHours[2] != 0 {
+		t.Fatalf("unexpected skipHours %v", feed.SkipHours)
+	}
+
+	if len(feed.SkipDays) != 2 || feed.SkipDays[0] != time.Saturday || feed.SkipDays[1] != time.Sunday {
+		t.Fatalf("unexpected skipDays %v", feed.SkipDays)
+	}
+
+	cet := time.FixedZone("CET", 1*3600)
+	cases := []struct {
+		at       time.Time
+		expected bool
+	}{
+		{time.Date(2023, 7, 19, 12, 0, 0, 0, time.UTC), false}, // Wednesday
+		{time.Date(2023, 7, 19, 0, 30, 0, 0, time.UTC), true},
+		{time.Date(2023, 7, 22, 12, 0, 0, 0, time.UTC), true}, // Saturday
+		{time.Date(2023, 7, 19, 1, 30, 0, 0, cet), true},      // 00:30 GMT
+		{time.Date(2023, 7, 19, 0, 30, 0, 0, cet), false},     // 23:30 GMT on Tuesday
+	}
+
+	for _, c := range cases {
+		if got := feed.Skips(c.at); got != c.expected {
+			t.Errorf("Skips(%s): expected %t, got %t", c.at, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// xmlLayout describes where the items of an XML feed format are, and how to convert them
+type xmlLayout struct {
+	// root checks the root element of the document
+	root func(e *etree.Element) error
+	// container reports whether e is the element whose children are the items, like the <channel> of RSS 2.0
+	container func(e *etree.Element) bool
+	// noContainer is returned when the document ends without a container
+	noContainer error
+	// isItem reports whether a child of the container is an item
+	isItem func(e *etree.Element) bool
+	// link returns the element with the link of the channel, which URLs are resolved against, or nil
+	link func(container *etree.Element) *etree.Element
+	// channel returns the element with the metadata of the channel, the container itself if nil
+	channel func(container *etree.Element) *etree.Element
+	// feed converts the container, with the elements before the first item, to a Feed
+	feed func(container *etree.Element, d *diagnostics) (*Feed, error)
+	item func(e *etree.Element, feed *Feed, d *diagnostics) (*Item, error)
+}
+
+// xmlStream builds the elements of a document from its tokens, like etree does, but returns the items one at a time
+// An item is attached to its container (so the namespaces of its ancestors resolve) until it is removed after conversion
+type xmlStream struct {
+	dec       *xml.Decoder
+	layout    *xmlLayout
+	doc       *etree.Document
+	stack     []*etree.Element
+	container *etree.Element
+	// items that were read ahead by readAll and are still attached to the container
+	pending []*etree.Element
+}
+
+func newXMLStream(r io.Reader, layout *xmlLayout) *xmlStream {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = passthroughCharsetReader
+	doc := etree.NewDocument()
+	return &xmlStream{dec: dec, layout: layout, doc: doc, stack: []*etree.Element{&doc.Element}}
+}
+
+// next reads until the end of the next item and returns it, or nil at the end of the document
+func (s *xmlStream) next() (*etree.Element, error) {
+	for {
+		t, err := s.dec.RawToken()
+		if err == io.EOF {
+			if len(s.stack) != 1 {
+				return nil, etree.ErrXML
+			}
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		top := s.stack[len(s.stack)-1]
+		switch t := t.(type) {
+		case xml.StartElement:
+			tag := t.Name.Local
+			if t.Name.Space != "" {
+				tag = t.Name.Space + ":" + tag
+			}
+			e := top.CreateElement(tag)
+			for _, a := range t.Attr {
+				key := a.Name.Local
+				if a.Name.Space != "" {
+					key = a.Name.Space + ":" + key
+				}
+				e.CreateAttr(key, a.Value)
+			}
+			s.stack = append(s.stack, e)
+
+			if len(s.stack) == 2 {
+				if err := s.layout.root(e); err != nil {
+					return nil, err
+				}
+			}
+			if s.container == nil && s.layout.container(e) {
+				s.container = e
+			}
+		case xml.EndElement:
+			if top.Tag != t.Name.Local || top.Space != t.Name.Space {
+				return nil, etree.ErrXML
+			}
+			s.stack = s.stack[:len(s.stack)-1]
+			if top.Parent() == s.container && s.container != nil && s.layout.isItem(top) {
+				return top, nil
+			}
+		case xml.CharData:
+			// the text of the document and the container is only whitespace between elements, which would pile up between items
+			if top != &s.doc.Element && top != s.container {
+				top.CreateText(string(t))
+			}
+		}
+	}
+}
+
+// readAll reads the rest of the document, so the container has all of its children. The items are returned by item in order
+func (s *xmlStream) readAll() error {
+	for {
+		e, err := s.next()
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return nil
+		}
+		s.pending = append(s.pending, e)
+	}
+}
+
+// detachPending removes the items read ahead by readAll from the container, once the channel was converted. item attaches them again one at a time
+func (s *xmlStream) detachPending() {
+	for _, e := range s.pending {
+		s.container.RemoveChild(e)
+	}
+}
+
+// item returns the next item, from those read ahead by readAll or else from the document, or nil at the end of the document
+func (s *xmlStream) item() (*etree.Element, error) {
+	if len(s.pending) > 0 {
+		e := s.pending[0]
+		s.pending = s.pending[1:]
+		if e.Parent() == nil {
+			s.container.AddChild(e)
+		}
+		return e, nil
+	}
+	return s.next()
+}
+
+// isRoot reports whether e is the root element of its document
+func isRoot(e *etree.Element) bool {
+	return e.Parent() != nil && e.Parent().Parent() == nil
+}
+
+// streamXML parses the elements of the document up to the first item into a Feed, and returns an iterator over the items
+// In strict mode, the iterator yields a *StrictError and stops at the first item with a problem
+func streamXML(r io.Reader, layout *xmlLayout, opts *ParseOptions) (*Feed, iter.Seq2[*Item, error], error) {
+	return streamElements(newXMLStream(r, layout), opts)
+}
+
+// streamElements converts the container of s, with the children read so far, into a Feed, and returns an iterator over the items of s
+func streamElements(s *xmlStream, opts *ParseOptions) (*Feed, iter.Seq2[*Item, error], error) {
+	layout := s.layout
+	first, err := s.item()
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.container == nil {
+		return nil, nil, layout.noContainer
+	}
+
+	// the URLs of the items are resolved as they are read
+	fallback := resolveChannel(s.container, layout, opts.baseURL())
+	itemBase := xmlBase(s.container, fallback)
+
+	d := &diagnostics{}
+	feed, err := layout.feed(s.container, d)
+	if err != nil {
+		return nil, nil, err
+	}
+	channel := s.container
+	if layout.channel != nil {
+		channel = layout.channel(s.container)
+	}
+	extensions := opts.extensions()
+	feed.Extensions = parseExtensions(channel, extensions, false, d)
+	// only one item is in the document at a time, like when streaming
+	s.detachPending()
+
+	strict := opts != nil && opts.Strict
+	if strict && len(d.warnings) > 0 {
+		return nil, nil, &StrictError{Warnings: d.warnings}
+	}
+	feed.Warnings = d.warnings
+
+	items := func(yield func(*Item, error) bool) {
+		n := 0
+		for e := first; e != nil; {
+			n++
+			from := len(d.warnings)
+			path := elementPath(e)
+
+			resolveTree(e, itemBase)
+			item, err := layout.item(e, feed, d)
+			if err != nil {
+				d.warn(path, SeverityError, "failed to parse <%s>: %s", e.Tag, err)
+			}
+			if item != nil {
+				item.Extensions = parseExtensions(e, extensions, true, d)
+				if item.GUID == "" {
+					item.GUID = synthesizeGUID(item)
+				}
+			}
+			// only one item is in the document at a time, so its position is added here
+			numberPaths(d.warnings[from:], path, n)
+			s.container.RemoveChild(e)
+			feed.Warnings = d.warnings
+
+			if strict && len(d.warnings) > from {
+				yield(nil, &StrictError{Warnings: d.warnings[from:]})
+				return
+			}
+			if item != nil && !yield(item, nil) {
+				return
+			}
+
+			if e, err = s.item(); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+
+	return feed, items, nil
+}
+
+// numberPaths adds the 1-based position n to the item at path in the paths of warnings
+func numberPaths(warnings []*Warning, path string, n int) {
+	for _, w := range warnings {
+		if w.Path == path || strings.HasPrefix(w.Path, path+"/") {
+			w.Path = fmt.Sprintf("%s[%d]%s", path, n, w.Path[len(path):])
+		}
+	}
+}
+
+// parseXML parses a whole document with layout. Problems are collected leniently, so strict mode reports all of them
+// Unlike Stream, the document is read to the end before the channel is converted, so elements of the channel after the items are kept
+func parseXML(r io.Reader, layout *xmlLayout, opts *ParseOptions) (*Feed, error) {
+	s := newXMLStream(r, layout)
+	if err := s.readAll(); err != nil {
+		return nil, err
+	}
+
+	feed, items, err := streamElements(s, &ParseOptions{BaseURL: opts.baseURL(), Extensions: opts.extensions()})
+	if err != nil {
+		return nil, err
+	}
+
+	for item, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	d := &diagnostics{warnings: feed.Warnings}
+	return d.finish(feed, opts)
+}
+
+// Stream takes a reader with a feed in any supported format, like Parse, and returns the feed without its Items and an iterator over them.
+// XML feeds are parsed one item at a time as the iterator advances, so memory use does not grow with the number of items,
+// and breaking out of the loop stops reading. Elements of the channel that come after the first item are ignored.
+// JSON Feeds are decoded whole before the iterator is returned.
+// The iterator can only be used once. The Warnings of the feed grow as items are parsed; in strict mode the iterator yields a *StrictError
+// and stops at the first item with a problem
+func Stream(r io.Reader, contentType string, opts *ParseOptions) (*Feed, iter.Seq2[*Item, error], error) {
+	format, r, err := DetectFormat(r, contentType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch format {
+	case FormatRSS:
+		return streamXML(r, rssLayout(), opts)
+	case FormatAtom:
+		return streamXML(r, atomLayout(), opts)
+	case FormatRDF:
+		return streamXML(r, rdfLayout(), opts)
+	case FormatJSON:
+		feed, err := ParseJSONFeedWithOptions(r, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		items := feed.Items
+		feed.Items = nil
+		return feed, func(yield func(*Item, error) bool) {
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}, nil
+	default:
+		return nil, nil, &UnknownFormatError{ContentType: contentType}
+	}
+}
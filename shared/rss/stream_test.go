@@ -0,0 +1,168 @@
+package rss_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// bigFeed returns an RSS feed with n items, newest first, generated as it is read
+func bigFeed(n int) io.Reader {
+	readers := []io.Reader{strings.NewReader(`<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/"><channel><title>Archive</title><link>https://example.com/</link><description>Everything</description>`)}
+	for i := n; i > 0; i-- {
+		readers = append(readers, strings.NewReader(fmt.Sprintf(`<item><title>Post %d</title><guid>%d</guid><dc:creator>Someone</dc:creator></item>`, i, i)))
+	}
+	readers = append(readers, strings.NewReader(`</channel></rss>`))
+	return io.MultiReader(readers...)
+}
+
+func TestStream(t *testing.T) {
+	feed, items, err := rss.Stream(bigFeed(10000), "", nil)
+	if err != nil {
+		t.Fatalf("Stream: %s", err)
+	}
+
+	if feed.Title != "Archive" || len(feed.Items) != 0 {
+		t.Fatalf("unexpected feed %+v", feed)
+	}
+
+	// stop once a known item is reached
+	var got []string
+	for item, err := range items {
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if item.GUID == "9997" {
+			break
+		}
+		if item.Feed != feed || item.Author != "Someone" {
+			t.Fatalf("unexpected item %+v", item)
+		}
+		got = append(got, item.GUID)
+	}
+
+	if strings.Join(got, " ") != "10000 9999 9998" {
+		t.Fatalf("unexpected items %v", got)
+	}
+}
+
+func TestStreamAllFormats(t *testing.T) {
+	cases := []struct {
+		name  string
+		in    string
+		items int
+	}{
+		{"rss", rss2String, 5},
+		{"atom", atomString, 2},
+		{"rdf", rdfString, 2},
+		{"json", jsonFeedString, 2},
+	}
+
+	for _, c := range cases {
+		feed, items, err := rss.Stream(strings.NewReader(c.in), "", nil)
+		if err != nil {
+			t.Fatalf("%s: Stream: %s", c.name, err)
+		}
+
+		n := 0
+		for item, err := range items {
+			if err != nil {
+				t.Fatalf("%s: %s", c.name, err)
+			}
+			if item.Feed != feed {
+				t.Fatalf("%s: item does not point to its feed", c.name)
+			}
+			n++
+		}
+
+		if n != c.items {
+			t.Fatalf("%s: expected %d items, got %d", c.name, c.items, n)
+		}
+	}
+}
+
+func TestStreamStrict(t *testing.T) {
+	in := strings.Replace(rss2String, "<pubDate>Fri, 21 Jul 2023 09:04 EDT</pubDate>", "<pubDate>someday</pubDate>", 1)
+
+	_, items, err := rss.Stream(strings.NewReader(in), "", &rss.ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("Stream: %s", err)
+	}
+
+	n := 0
+	var strictErr *rss.StrictError
+	for _, err := range items {
+		if err != nil {
+			if !errors.As(err, &strictErr) {
+				t.Fatalf("expected a StrictError, got %v", err)
+			}
+			break
+		}
+		n++
+	}
+
+	if strictErr == nil || strictErr.Warnings[0].Path != "/rss/channel/item[1]/pubDate" {
+		t.Fatalf("expected a StrictError for the first item, got %v", strictErr)
+	}
+	if n != 0 {
+		t.Fatalf("expected no items before the error, got %d", n)
+	}
+}
+
+func TestStreamMalformed(t *testing.T) {
+	_, items, err := rss.Stream(strings.NewReader(`<rss><channel><title>a</title><link>b</link><description>c</description><item><title>x</title></item><item>`), "", nil)
+	if err != nil {
+		t.Fatalf("Stream: %s", err)
+	}
+
+	var errs int
+	for _, err := range items {
+		if err != nil {
+			errs++
+		}
+	}
+
+	if errs != 1 {
+		t.Fatalf("expected an error for the truncated document, got %d", errs)
+	}
+}
+
+func TestParseMetadataAfterItems(t *testing.T) {
+	const in = `<?xml version="1.0"?>
+<rss version="2.0">
+   <channel>
+      <item><title>First</title><link>/posts/1</link></item>
+      <item><title>Second</title><guid isPermaLink="true">post-2</guid></item>
+      <title>Late</title>
+      <link>https://example.com/</link>
+      <description>Metadata after the items</description>
+      <ttl>30</ttl>
+      <image><url>https://example.com/logo.png</url><title>Late</title><link>https://example.com/</link></image>
+   </channel>
+</rss>`
+
+	feed, err := rss.ParseRSS(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+	if feed.Title != "Late" || feed.TTL != 30 || feed.Image == nil || len(feed.Items) != 2 {
+		t.Fatalf("expected the metadata after the items, got %+v", feed)
+	}
+	// the link after the items is still the base of their URLs, and warnings are numbered by item
+	if feed.Items[0].Link.String() != "https://example.com/posts/1" {
+		t.Fatalf("unexpected item link %s", feed.Items[0].Link)
+	}
+	if len(feed.Warnings) != 1 || feed.Warnings[0].Path != "/rss/channel/item[2]/guid" {
+		t.Fatalf("unexpected warnings %v", feed.Warnings)
+	}
+
+	// streaming only has the elements before the first item
+	if _, _, err := rss.Stream(strings.NewReader(in), "", nil); err == nil {
+		t.Fatal("expected streaming to miss the <title> after the items")
+	}
+}
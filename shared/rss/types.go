@@ -14,25 +14,82 @@ type Feed struct {
 	Link *url.URL
 	// The URL that this feed can be retrieved from. Different from Link
 	FetchFrom *url.URL
-	Language  string
+	// The WebSub hub that pushes new content of the feed to subscribers, from a link with rel="hub". nil if the feed has none
+	Hub      *url.URL
+	Language string
+	// The copyright notice of the feed, from <copyright>, Atom <rights> or <dc:rights>
+	Copyright string
+	// The publisher of the feed, from <dc:publisher>
+	Publisher string
+	// The email addresses of the people responsible for the content and for the technical side of the feed, usually followed by their names
+	ManagingEditor string
+	WebMaster      string
+	// The publication date of the content and the date the feed last changed, nil if not specified
+	PubDate       *time.Time
+	LastBuildDate *time.Time
+	// The program that generated the feed
+	Generator string
+	// The documentation of the format the feed uses
+	Docs *url.URL
+	// The PICS rating of the feed
+	Rating string
+	// The icon or logo of the feed, nil if it has none
+	Image *Image
+	// A text box that can be shown with the feed, nil if it has none
+	TextInput *TextInput
+	// The rssCloud service that notifies subscribers of updates to the feed, nil if it has none
+	Cloud *Cloud
 	// The time-to-live of the feed. Time in minutes that the reader should wait between each refresh
-	TTL   int
-	Items []*Item
+	TTL int
+	// The hours (0-23, in GMT) and days in which the reader should not refresh the feed, from <skipHours> and <skipDays>
+	SkipHours  []int
+	SkipDays   []time.Weekday
+	Categories []*Category
+	// The links to the older and newer documents of a paged or archived feed, nil if it has none
+	Paging *Paging
+	// Podcast metadata from the iTunes namespace, nil if the feed has none
+	ITunes *ITunesFeed
+	// Podcast metadata from the Podcasting 2.0 namespace, nil if the feed has none
+	Podcast *PodcastFeed
+	Items   []*Item
+	// The data of namespace extensions of the channel, nil if it has none, see ExtensionRegistry
+	Extensions Extensions
+	// Problems found while parsing the feed in lenient mode, see ParseOptions
+	Warnings []*Warning
 }
 
 // Item represents an RSS item/post
 type Item struct {
 	DatabaseID int
 	// The feed this item came from
-	Feed        *Feed
-	GUID        string
-	Title       string
-	Description string
-	Link        *url.URL
-	Author      string
-	PubDate     *time.Time
-	Read        bool
-	Enclosure   *Enclosure
+	Feed *Feed
+	// The unique identifier of the item. Items without one get a GUID derived from their link, title, enclosure and date
+	GUID string
+	// Whether the GUID is the URL of the item, from the isPermaLink attribute of an RSS <guid>
+	GUIDIsPermaLink bool
+	Title           string
+	Description     string
+	// The full content of the item, when the feed provides it separately from the Description
+	Content string
+	// The Description without markup, set when the item is sanitized, see sanitize.Text
+	DescriptionText string
+	Link            *url.URL
+	// The authors as written in the feed, like "joe@example.com (Joe Bloggs)" in RSS, or their names joined with ", "
+	Author string
+	// The authors of the item. Atom entries and JSON Feed items without authors inherit those of the feed
+	Authors    []*Person
+	PubDate    *time.Time
+	Read       bool
+	Enclosures []*Enclosure
+	Categories []*Category
+	// Podcast episode metadata from the iTunes namespace, nil if the item has none
+	ITunes *ITunesItem
+	// Podcast episode metadata from the Podcasting 2.0 namespace, nil if the item has none
+	Podcast *PodcastItem
+	// Media objects from the Media RSS namespace
+	Media []*Media
+	// The data of namespace extensions of the item, nil if it has none, see ExtensionRegistry
+	Extensions Extensions
 }
 
 // Enclosure represents an RSS enclosure, usually media associated with an item
@@ -42,3 +99,177 @@ type Enclosure struct {
 	MimeType string
 	Length   int
 }
+
+// Person represents the author of an item, from an RSS <author> or <dc:creator>, an Atom <author> or a JSON Feed author object
+type Person struct {
+	Name  string
+	Email string
+	// A page about the person, from the Atom <uri> or the JSON Feed url
+	URI *url.URL
+}
+
+// Image represents the <image> of a channel, or the icon or logo of an Atom feed or JSON Feed
+type Image struct {
+	URL   *url.URL
+	Title string
+	// The page the image links to, usually the same as the Link of the feed
+	Link *url.URL
+	// The dimensions of the image in pixels, 0 if not specified
+	Width       int
+	Height      int
+	Description string
+}
+
+// TextInput represents the <textInput> of a channel, a form that submits a single field to Link
+type TextInput struct {
+	Title       string
+	Description string
+	// The name of the text field
+	Name string
+	Link *url.URL
+}
+
+// Cloud represents the <cloud> of a channel, a service that notifies registered readers when the feed is updated. See https://www.rssboard.org/rsscloud-interface
+type Cloud struct {
+	Domain string
+	Port   int
+	Path   string
+	// The procedure that is called to register for notifications, for the xml-rpc and soap protocols
+	RegisterProcedure string
+	// "xml-rpc", "soap" or "http-post"
+	Protocol string
+}
+
+// Category represents a <category> of a channel or an item
+type Category struct {
+	Term string
+	// The taxonomy the term belongs to, from the domain attribute or the Atom scheme
+	Domain string
+}
+
+// ITunesFeed represents the podcast metadata of a channel in the iTunes namespace
+// See https://help.apple.com/itc/podcasts_connect/#/itcb54353390
+type ITunesFeed struct {
+	Author  string
+	Summary string
+	// The cover art of the podcast
+	Image    *url.URL
+	Explicit bool
+	// Either "episodic" or "serial"
+	Type string
+}
+
+// ITunesItem represents the podcast metadata of an episode in the iTunes namespace
+type ITunesItem struct {
+	Author  string
+	Summary string
+	// The cover art of the episode, overriding the cover art of the podcast
+	Image    *url.URL
+	Duration time.Duration
+	// The episode and season numbers, 0 if not specified
+	Episode  int
+	Season   int
+	Explicit bool
+	// Either "full", "trailer" or "bonus"
+	EpisodeType string
+}
+
+// PodcastFeed represents the podcast metadata of a channel in the Podcasting 2.0 namespace
+// See https://podcastindex.org/namespace/1.0
+type PodcastFeed struct {
+	// The globally unique identifier of the podcast, from <podcast:guid>
+	GUID string
+	// Whether the podcast must not be imported into another hosting platform, from <podcast:locked>
+	Locked bool
+	// The email address that can unlock the podcast
+	LockedOwner string
+	Funding     []*PodcastFunding
+	Persons     []*PodcastPerson
+}
+
+// PodcastItem represents the podcast metadata of an episode in the Podcasting 2.0 namespace
+type PodcastItem struct {
+	Transcripts []*PodcastTranscript
+	// nil if the episode has no chapters file
+	Chapters *PodcastChapters
+	Persons  []*PodcastPerson
+}
+
+// PodcastTranscript represents a <podcast:transcript>, a link to the transcript or captions of an episode
+type PodcastTranscript struct {
+	URL      *url.URL
+	MimeType string
+	Language string
+	// "captions" if the file is a captions file with timing information
+	Rel string
+}
+
+// PodcastChapters represents a <podcast:chapters>, a link to the chapters file of an episode
+type PodcastChapters struct {
+	URL      *url.URL
+	MimeType string
+}
+
+// PodcastPerson represents a <podcast:person>, someone involved with a podcast or an episode
+type PodcastPerson struct {
+	Name string
+	// The role and group from the Podcast Taxonomy Project, like "host" in "cast"
+	Role  string
+	Group string
+	// A picture of the person
+	Image *url.URL
+	// A page about the person
+	Href *url.URL
+}
+
+// PodcastFunding represents a <podcast:funding>, a link to donate to or support a podcast
+type PodcastFunding struct {
+	URL  *url.URL
+	Text string
+}
+
+// Media represents a media object from the Media RSS namespace, in one or more renditions
+// A <media:group> becomes a single Media, and so does every <media:content> outside of a group
+// See https://www.rssboard.org/media-rss
+type Media struct {
+	Title       string
+	Description string
+	Thumbnails  []*MediaThumbnail
+	// The renditions of the object, like the same video in several resolutions. Empty if the item only has thumbnails
+	Contents []*MediaContent
+}
+
+// MediaContent represents a <media:content>, a single rendition of a media object
+type MediaContent struct {
+	URL      *url.URL
+	MimeType string
+	// One of "image", "audio", "video", "document" or "executable"
+	Medium string
+	// The size of the file in bytes
+	FileSize int64
+	Duration time.Duration
+	Width    int
+	Height   int
+	// The bitrate in kilobits per second
+	Bitrate int
+	Lang    string
+	// Whether this is the default rendition of its group
+	IsDefault bool
+}
+
+// MediaThumbnail represents a <media:thumbnail>
+type MediaThumbnail struct {
+	URL    *url.URL
+	Width  int
+	Height int
+}
+
+// Attachment represents a file referenced by an item, like a podcast transcript or chapters file, as fetched by the daemon
+type Attachment struct {
+	URL         *url.URL
+	ContentType string
+	Data        []byte
+	// The validators of the response, used to refetch the file conditionally
+	ETag         string
+	LastModified time.Time
+}
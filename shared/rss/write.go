@@ -0,0 +1,478 @@
+package rss
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// The prefixes the writers use for the namespaces they support
+var writerNamespaces = map[string]string{
+	"content": contentNS,
+	"dc":      dcNS,
+	"atom":    atomNS,
+	"itunes":  itunesNS,
+	"podcast": podcastNS,
+	"media":   mediaNS,
+	"fh":      fhNS,
+}
+
+// xmlWriter builds an XML document, declaring the namespace of every prefixed element it creates on the root
+type xmlWriter struct {
+	doc  *etree.Document
+	root *etree.Element
+}
+
+func newXMLWriter(root string) *xmlWriter {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	return &xmlWriter{doc: doc, root: doc.CreateElement(root)}
+}
+
+// element creates a child element of parent named tag, which may have one of the prefixes in writerNamespaces
+func (x *xmlWriter) element(parent *etree.Element, tag string) *etree.Element {
+	if prefix, _, ok := strings.Cut(tag, ":"); ok && x.root.SelectAttr("xmlns:"+prefix) == nil {
+		x.root.CreateAttr("xmlns:"+prefix, writerNamespaces[prefix])
+	}
+	return parent.CreateElement(tag)
+}
+
+// text creates a child element of parent named tag with text, unless text is empty
+func (x *xmlWriter) text(parent *etree.Element, tag, text string) *etree.Element {
+	if text == "" {
+		return nil
+	}
+	el := x.element(parent, tag)
+	el.SetText(text)
+	return el
+}
+
+// textURL creates a child element of parent named tag with u as its text, unless u is nil
+func (x *xmlWriter) textURL(parent *etree.Element, tag string, u *url.URL) *etree.Element {
+	if u == nil {
+		return nil
+	}
+	return x.text(parent, tag, u.String())
+}
+
+// textInt creates a child element of parent named tag with i as its text, unless i is 0
+func (x *xmlWriter) textInt(parent *etree.Element, tag string, i int) *etree.Element {
+	if i == 0 {
+		return nil
+	}
+	return x.text(parent, tag, strconv.Itoa(i))
+}
+
+// textDate creates a child element of parent named tag with t in layout as its text, unless t is nil
+func (x *xmlWriter) textDate(parent *etree.Element, tag string, t *time.Time, layout string) *etree.Element {
+	if t == nil {
+		return nil
+	}
+	return x.text(parent, tag, t.Format(layout))
+}
+
+// namespacePrefix returns the prefix of the namespace uri, declaring it on the root if it is not declared yet.
+// The prefix is the one of writerNamespaces for a supported namespace, otherwise preferred unless it is empty or taken, or a new one like "ns1"
+func (x *xmlWriter) namespacePrefix(uri, preferred string) string {
+	for _, a := range x.root.Attr {
+		if a.Space == "xmlns" && a.Value == uri {
+			return a.Key
+		}
+	}
+
+	prefix := preferred
+	for p, u := range writerNamespaces {
+		if u == uri {
+			prefix = p
+		}
+	}
+	for i := 1; prefix == "" || prefix == "xml" || x.root.SelectAttr("xmlns:"+prefix) != nil || (writerNamespaces[prefix] != "" && writerNamespaces[prefix] != uri); i++ {
+		prefix = fmt.Sprintf("ns%d", i)
+	}
+	x.root.CreateAttr("xmlns:"+prefix, uri)
+	return prefix
+}
+
+// writeRaw adds raw to e as it was in the document
+func (x *xmlWriter) writeRaw(e *etree.Element, raw *RawElement) {
+	tag := raw.Name
+	if raw.Namespace != "" {
+		tag = x.namespacePrefix(raw.Namespace, raw.Prefix) + ":" + raw.Name
+	}
+	el := e.CreateElement(tag)
+	// elements without a namespace must not end up in the default namespace of an Atom document
+	if raw.Namespace == "" && x.root.SelectAttr("xmlns") != nil {
+		el.CreateAttr("xmlns", "")
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(raw.Attrs)) {
+		name := key
+		if prefix, local, ok := strings.Cut(key, ":"); ok && raw.AttrNamespaces[prefix] != "" {
+			name = x.namespacePrefix(raw.AttrNamespaces[prefix], prefix) + ":" + local
+		}
+		el.CreateAttr(name, raw.Attrs[key])
+	}
+	if raw.Text != "" {
+		el.SetText(raw.Text)
+	}
+	for _, c := range raw.Children {
+		x.writeRaw(el, c)
+	}
+}
+
+// writeExtensions adds the elements in ext that were kept from unknown namespaces to e. Data stored by an ExtensionHandler is not written
+func (x *xmlWriter) writeExtensions(e *etree.Element, ext Extensions) {
+	for _, uri := range slices.Sorted(maps.Keys(ext)) {
+		for _, raw := range ext.Raw(uri) {
+			x.writeRaw(e, raw)
+		}
+	}
+}
+
+// write writes the document to w, indented
+func (x *xmlWriter) write(w io.Writer) error {
+	x.doc.Indent(2)
+	_, err := x.doc.WriteTo(w)
+	return err
+}
+
+// setAttr sets the attribute key of e to value, unless value is empty
+func setAttr(e *etree.Element, key, value string) {
+	if value != "" {
+		e.CreateAttr(key, value)
+	}
+}
+
+// setAttrInt sets the attribute key of e to i, unless i is 0
+func setAttrInt(e *etree.Element, key string, i int64) {
+	if i != 0 {
+		e.CreateAttr(key, strconv.FormatInt(i, 10))
+	}
+}
+
+// setAttrURL sets the attribute key of e to u, unless u is nil
+func setAttrURL(e *etree.Element, key string, u *url.URL) {
+	if u != nil {
+		e.CreateAttr(key, u.String())
+	}
+}
+
+// seconds formats d as a number of seconds, with a fraction if it has one
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// writeRSSAtomLink adds an <atom:link> with rel and href to e, unless href is nil
+// rel="self" has the URL the feed is fetched from, as recommended by the RSS Advisory Board
+func (x *xmlWriter) writeRSSAtomLink(e *etree.Element, rel string, href *url.URL) {
+	if href == nil {
+		return
+	}
+	link := x.element(e, "atom:link")
+	link.CreateAttr("href", href.String())
+	link.CreateAttr("rel", rel)
+}
+
+// writeITunesFeed adds the iTunes metadata of a channel to e
+func (x *xmlWriter) writeITunesFeed(e *etree.Element, it *ITunesFeed) {
+	if it == nil {
+		return
+	}
+	x.text(e, "itunes:author", it.Author)
+	x.text(e, "itunes:summary", it.Summary)
+	if it.Image != nil {
+		x.element(e, "itunes:image").CreateAttr("href", it.Image.String())
+	}
+	x.text(e, "itunes:explicit", strconv.FormatBool(it.Explicit))
+	x.text(e, "itunes:type", it.Type)
+}
+
+// writeITunesItem adds the iTunes metadata of an episode to e
+func (x *xmlWriter) writeITunesItem(e *etree.Element, it *ITunesItem) {
+	if it == nil {
+		return
+	}
+	x.text(e, "itunes:author", it.Author)
+	x.text(e, "itunes:summary", it.Summary)
+	if it.Image != nil {
+		x.element(e, "itunes:image").CreateAttr("href", it.Image.String())
+	}
+	if it.Duration != 0 {
+		x.text(e, "itunes:duration", seconds(it.Duration))
+	}
+	x.textInt(e, "itunes:episode", it.Episode)
+	x.textInt(e, "itunes:season", it.Season)
+	x.text(e, "itunes:explicit", strconv.FormatBool(it.Explicit))
+	x.text(e, "itunes:episodeType", it.EpisodeType)
+}
+
+// writePodcastPersons adds a <podcast:person> to e for every person
+func (x *xmlWriter) writePodcastPersons(e *etree.Element, persons []*PodcastPerson) {
+	for _, p := range persons {
+		el := x.element(e, "podcast:person")
+		setAttr(el, "role", p.Role)
+		setAttr(el, "group", p.Group)
+		setAttrURL(el, "img", p.Image)
+		setAttrURL(el, "href", p.Href)
+		el.SetText(p.Name)
+	}
+}
+
+// writePodcastFeed adds the Podcasting 2.0 metadata of a channel to e
+func (x *xmlWriter) writePodcastFeed(e *etree.Element, p *PodcastFeed) {
+	if p == nil {
+		return
+	}
+	x.text(e, "podcast:guid", p.GUID)
+	if p.Locked || p.LockedOwner != "" {
+		locked := x.text(e, "podcast:locked", "no")
+		if p.Locked {
+			locked.SetText("yes")
+		}
+		setAttr(locked, "owner", p.LockedOwner)
+	}
+	for _, f := range p.Funding {
+		el := x.element(e, "podcast:funding")
+		setAttrURL(el, "url", f.URL)
+		el.SetText(f.Text)
+	}
+	x.writePodcastPersons(e, p.Persons)
+}
+
+// writePodcastItem adds the Podcasting 2.0 metadata of an episode to e
+func (x *xmlWriter) writePodcastItem(e *etree.Element, p *PodcastItem) {
+	if p == nil {
+		return
+	}
+	for _, t := range p.Transcripts {
+		el := x.element(e, "podcast:transcript")
+		setAttrURL(el, "url", t.URL)
+		setAttr(el, "type", t.MimeType)
+		setAttr(el, "language", t.Language)
+		setAttr(el, "rel", t.Rel)
+	}
+	if p.Chapters != nil {
+		el := x.element(e, "podcast:chapters")
+		setAttrURL(el, "url", p.Chapters.URL)
+		setAttr(el, "type", p.Chapters.MimeType)
+	}
+	x.writePodcastPersons(e, p.Persons)
+}
+
+// writeMediaMetadata adds the title, description and thumbnails of m to e
+func (x *xmlWriter) writeMediaMetadata(e *etree.Element, m *Media) {
+	x.text(e, "media:title", m.Title)
+	x.text(e, "media:description", m.Description)
+	for _, t := range m.Thumbnails {
+		el := x.element(e, "media:thumbnail")
+		setAttrURL(el, "url", t.URL)
+		setAttrInt(el, "width", int64(t.Width))
+		setAttrInt(el, "height", int64(t.Height))
+	}
+}
+
+// writeMediaContent adds a <media:content> for c to e
+func (x *xmlWriter) writeMediaContent(e *etree.Element, c *MediaContent) *etree.Element {
+	el := x.element(e, "media:content")
+	setAttrURL(el, "url", c.URL)
+	setAttr(el, "type", c.MimeType)
+	setAttr(el, "medium", c.Medium)
+	setAttrInt(el, "fileSize", c.FileSize)
+	if c.Duration != 0 {
+		el.CreateAttr("duration", seconds(c.Duration))
+	}
+	setAttrInt(el, "width", int64(c.Width))
+	setAttrInt(el, "height", int64(c.Height))
+	setAttrInt(el, "bitrate", int64(c.Bitrate))
+	setAttr(el, "lang", c.Lang)
+	if c.IsDefault {
+		el.CreateAttr("isDefault", "true")
+	}
+	return el
+}
+
+// writeMedia adds the media objects of an item to e. A Media with several renditions becomes a <media:group>,
+// one with a single rendition a <media:content>, and one without renditions is written as the metadata of the item
+func (x *xmlWriter) writeMedia(e *etree.Element, media []*Media) {
+	for _, m := range media {
+		switch len(m.Contents) {
+		case 0:
+			x.writeMediaMetadata(e, m)
+		case 1:
+			x.writeMediaMetadata(x.writeMediaContent(e, m.Contents[0]), m)
+		default:
+			group := x.element(e, "media:group")
+			for _, c := range m.Contents {
+				x.writeMediaContent(group, c)
+			}
+			x.writeMediaMetadata(group, m)
+		}
+	}
+}
+
+// writeRSSCategories adds a <category> to e for every category
+func (x *xmlWriter) writeRSSCategories(e *etree.Element, categories []*Category) {
+	for _, c := range categories {
+		el := x.text(e, "category", c.Term)
+		if el != nil {
+			setAttr(el, "domain", c.Domain)
+		}
+	}
+}
+
+// writeRSSChannel adds the elements of feed, without its items, to the <channel> e
+func (x *xmlWriter) writeRSSChannel(e *etree.Element, feed *Feed) {
+	// title, link and description are required, even if empty
+	x.element(e, "title").SetText(feed.Title)
+	link := x.element(e, "link")
+	if feed.Link != nil {
+		link.SetText(feed.Link.String())
+	}
+	x.element(e, "description").SetText(feed.Description)
+	x.writeRSSAtomLink(e, "self", feed.FetchFrom)
+	x.writeRSSAtomLink(e, "hub", feed.Hub)
+	x.writePaging(e, feed.Paging, "atom:link")
+
+	x.text(e, "language", feed.Language)
+	x.text(e, "copyright", feed.Copyright)
+	x.text(e, "managingEditor", feed.ManagingEditor)
+	x.text(e, "webMaster", feed.WebMaster)
+	x.textDate(e, "pubDate", feed.PubDate, time.RFC1123Z)
+	x.textDate(e, "lastBuildDate", feed.LastBuildDate, time.RFC1123Z)
+	x.writeRSSCategories(e, feed.Categories)
+	x.text(e, "generator", feed.Generator)
+	x.textURL(e, "docs", feed.Docs)
+	if c := feed.Cloud; c != nil {
+		el := x.element(e, "cloud")
+		el.CreateAttr("domain", c.Domain)
+		el.CreateAttr("port", strconv.Itoa(c.Port))
+		el.CreateAttr("path", c.Path)
+		el.CreateAttr("registerProcedure", c.RegisterProcedure)
+		el.CreateAttr("protocol", c.Protocol)
+	}
+	x.textInt(e, "ttl", feed.TTL)
+
+	if img := feed.Image; img != nil {
+		el := x.element(e, "image")
+		x.textURL(el, "url", img.URL)
+		x.element(el, "title").SetText(img.Title)
+		link := x.element(el, "link")
+		if img.Link != nil {
+			link.SetText(img.Link.String())
+		}
+		x.textInt(el, "width", img.Width)
+		x.textInt(el, "height", img.Height)
+		x.text(el, "description", img.Description)
+	}
+
+	x.text(e, "rating", feed.Rating)
+
+	if ti := feed.TextInput; ti != nil {
+		el := x.element(e, "textInput")
+		x.element(el, "title").SetText(ti.Title)
+		x.element(el, "description").SetText(ti.Description)
+		x.element(el, "name").SetText(ti.Name)
+		x.textURL(el, "link", ti.Link)
+	}
+
+	if len(feed.SkipHours) > 0 {
+		el := x.element(e, "skipHours")
+		for _, h := range feed.SkipHours {
+			x.element(el, "hour").SetText(strconv.Itoa(h))
+		}
+	}
+	if len(feed.SkipDays) > 0 {
+		el := x.element(e, "skipDays")
+		for _, d := range feed.SkipDays {
+			x.element(el, "day").SetText(d.String())
+		}
+	}
+
+	x.text(e, "dc:publisher", feed.Publisher)
+	x.writeITunesFeed(e, feed.ITunes)
+	x.writePodcastFeed(e, feed.Podcast)
+	x.writeExtensions(e, feed.Extensions)
+}
+
+// writeRSSItem adds an <item> for item to e
+func (x *xmlWriter) writeRSSItem(e *etree.Element, item *Item) {
+	el := x.element(e, "item")
+	x.text(el, "title", item.Title)
+	x.textURL(el, "link", item.Link)
+	x.text(el, "description", item.Description)
+	x.text(el, "content:encoded", item.Content)
+
+	// <author> must be an email address, usually followed by a name, authors without one are written as <dc:creator>
+	switch {
+	case len(item.Authors) > 0:
+		tag := "author"
+		for _, p := range item.Authors {
+			if p.Email == "" {
+				tag = "dc:creator"
+			}
+		}
+		for _, p := range item.Authors {
+			x.text(el, tag, p.rssString())
+		}
+	case strings.Contains(item.Author, "@"):
+		x.text(el, "author", item.Author)
+	default:
+		x.text(el, "dc:creator", item.Author)
+	}
+
+	x.writeRSSCategories(el, item.Categories)
+
+	for _, enc := range item.Enclosures {
+		encEl := x.element(el, "enclosure")
+		setAttrURL(encEl, "url", enc.URL)
+		encEl.CreateAttr("length", strconv.Itoa(enc.Length))
+		setAttr(encEl, "type", enc.MimeType)
+	}
+
+	if guid := x.text(el, "guid", item.GUID); guid != nil && !item.GUIDIsPermaLink {
+		guid.CreateAttr("isPermaLink", "false")
+	}
+	x.textDate(el, "pubDate", item.PubDate, time.RFC1123Z)
+
+	x.writeITunesItem(el, item.ITunes)
+	x.writePodcastItem(el, item.Podcast)
+	x.writeMedia(el, item.Media)
+	x.writeExtensions(el, item.Extensions)
+}
+
+// WriteRSS renders feed with its Items as an RSS 2.0 document to w
+// Metadata without a native element is written in the Dublin Core, iTunes, Podcasting 2.0 and Media RSS namespaces, and the FetchFrom of the feed as an <atom:link rel="self">.
+// Elements kept from unknown namespaces are written back with their namespaces
+func WriteRSS(w io.Writer, feed *Feed) error {
+	x := newXMLWriter("rss")
+	x.root.CreateAttr("version", "2.0")
+
+	channel := x.element(x.root, "channel")
+	x.writeRSSChannel(channel, feed)
+	for _, item := range feed.Items {
+		x.writeRSSItem(channel, item)
+	}
+
+	return x.write(w)
+}
+
+// Write renders feed with its Items to w in format, see WriteRSS, WriteAtom and WriteJSONFeed. RDF cannot be written
+func Write(w io.Writer, feed *Feed, format Format) error {
+	switch format {
+	case FormatRSS:
+		return WriteRSS(w, feed)
+	case FormatAtom:
+		return WriteAtom(w, feed)
+	case FormatJSON:
+		return WriteJSONFeed(w, feed)
+	default:
+		return fmt.Errorf("cannot write feeds in format %s", format)
+	}
+}
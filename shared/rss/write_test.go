@@ -0,0 +1,207 @@
+package rss_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/rss"
+)
+
+// roundTrip writes feed in format and parses the result
+func roundTrip(t *testing.T, feed *rss.Feed, format rss.Format) (*rss.Feed, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := rss.Write(&buf, feed, format); err != nil {
+		t.Fatalf("Write %s: %s", format, err)
+	}
+	written := buf.String()
+
+	parsed, err := rss.ParseWithOptions(strings.NewReader(written), "", &rss.ParseOptions{Strict: format != rss.FormatAtom})
+	if err != nil {
+		t.Fatalf("failed to parse the written %s: %s\n%s", format, err, written)
+	}
+	return parsed, written
+}
+
+// sameAuthors reports whether a and b have the same names and email addresses. Only Atom and JSON Feed have URIs, and only RSS and Atom have email addresses
+func sameAuthors(a, b []*rss.Person) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || (a[i].Email != b[i].Email && a[i].Email != "" && b[i].Email != "") {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	fixtures := map[string]string{
+		"rss2":       rss2String,
+		"podcast":    podcastString,
+		"youtube":    youtubeString,
+		"atom":       atomString,
+		"rdf":        rdfString,
+		"jsonfeed":   jsonFeedString,
+		"extensions": extensionFeed,
+	}
+
+	for name, in := range fixtures {
+		for _, format := range []rss.Format{rss.FormatRSS, rss.FormatAtom, rss.FormatJSON} {
+			feed, err := rss.Parse(strings.NewReader(in), "")
+			if err != nil {
+				t.Fatalf("%s: Parse: %s", name, err)
+			}
+
+			// nothing is lost when the written feed is parsed and written again
+			once, first := roundTrip(t, feed, format)
+			_, second := roundTrip(t, once, format)
+			if first != second {
+				t.Errorf("%s as %s does not round-trip:\n%s\n---\n%s", name, format, first, second)
+				continue
+			}
+
+			if once.Title != feed.Title || len(once.Items) != len(feed.Items) {
+				t.Errorf("%s as %s: expected %q with %d items, got %q with %d", name, format, feed.Title, len(feed.Items), once.Title, len(once.Items))
+				continue
+			}
+			// JSON Feed has no place for the elements of unknown namespaces
+			if format != rss.FormatJSON && !reflect.DeepEqual(once.Extensions, feed.Extensions) {
+				t.Errorf("%s as %s: extensions changed from %v to %v", name, format, feed.Extensions, once.Extensions)
+			}
+			for i, item := range once.Items {
+				orig := feed.Items[i]
+				if item.GUID != orig.GUID || item.Title != orig.Title || item.Description != orig.Description || item.Content != orig.Content || !sameAuthors(item.Authors, orig.Authors) ||
+					len(item.Enclosures) != len(orig.Enclosures) || (orig.PubDate != nil && !item.PubDate.Equal(*orig.PubDate)) {
+					t.Errorf("%s as %s: item %d changed from %+v to %+v", name, format, i, orig, item)
+				}
+				if format != rss.FormatJSON && !reflect.DeepEqual(item.Extensions, orig.Extensions) {
+					t.Errorf("%s as %s: extensions of item %d changed from %v to %v", name, format, i, orig.Extensions, item.Extensions)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteExtensions(t *testing.T) {
+	// an attribute in another namespace than its element, a child without a namespace, and a prefix that the writers use themselves
+	doc := strings.Replace(extensionFeed, "<geo:Point>", `<geo:Point xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" rdf:about="#kremlin"><name>Kremlin</name>`, 1)
+	doc = strings.Replace(doc, `xmlns:wfw="http://wellformedweb.org/CommentAPI/"`, `xmlns:dc="http://wellformedweb.org/CommentAPI/"`, 1)
+	doc = strings.ReplaceAll(doc, "wfw:commentRss", "dc:commentRss")
+	feed, err := rss.ParseRSS(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	for _, format := range []rss.Format{rss.FormatRSS, rss.FormatAtom} {
+		got, written := roundTrip(t, feed, format)
+		if !reflect.DeepEqual(got.Extensions.Raw(geoNS), feed.Extensions.Raw(geoNS)) {
+			t.Fatalf("%s: expected %+v, got %+v\n%s", format, feed.Extensions.Raw(geoNS)[0], got.Extensions.Raw(geoNS), written)
+		}
+		if raw := got.Items[0].Extensions.Raw(wfwNS); len(raw) != 1 || raw[0].Text != "https://example.com/1/comments" {
+			t.Fatalf("%s: expected the comment feed, got %v\n%s", format, got.Items[0].Extensions, written)
+		}
+		if !strings.Contains(written, `rdf:about="#kremlin"`) || !strings.Contains(written, "<geo:Point") {
+			t.Fatalf("%s: expected the prefixes of the document, got\n%s", format, written)
+		}
+	}
+}
+
+func TestWriteAtomAuthors(t *testing.T) {
+	feed := &rss.Feed{Title: "Authors", Items: []*rss.Item{{
+		GUID:    "urn:example:1",
+		Title:   "Item",
+		Authors: []*rss.Person{{Email: "joe@example.com"}, {Name: "Jane Doe", Email: "jane@example.com"}},
+	}}}
+
+	got, written := roundTrip(t, feed, rss.FormatAtom)
+
+	authors := got.Items[0].Authors
+	if len(authors) != 2 || authors[0].Name != "" || authors[0].Email != "joe@example.com" || authors[1].Name != "Jane Doe" || authors[1].Email != "jane@example.com" {
+		t.Fatalf("expected the authors %+v %+v, got %+v\n%s", feed.Items[0].Authors[0], feed.Items[0].Authors[1], authors, written)
+	}
+	if strings.Count(written, "joe@example.com") != 1 {
+		t.Fatalf("expected the email address to be written once, got\n%s", written)
+	}
+}
+
+func TestWriteJSONFeedAuthors(t *testing.T) {
+	feed := &rss.Feed{Title: "Authors", Items: []*rss.Item{{
+		GUID:    "urn:example:1",
+		Title:   "Item",
+		Authors: []*rss.Person{{Email: "joe@example.com"}, {Name: "Jane Doe", Email: "jane@example.com"}},
+	}}}
+
+	got, written := roundTrip(t, feed, rss.FormatJSON)
+
+	// an email address is only kept when the person has no other URL
+	authors := got.Items[0].Authors
+	if len(authors) != 2 || authors[0].Name != "" || authors[0].Email != "joe@example.com" || authors[1].Name != "Jane Doe" {
+		t.Fatalf("expected the authors %+v %+v, got %+v\n%s", feed.Items[0].Authors[0], feed.Items[0].Authors[1], authors, written)
+	}
+	if strings.Contains(written, `"name": "joe@example.com"`) {
+		t.Fatalf("expected the email address not to be written as a name, got\n%s", written)
+	}
+}
+
+func TestWriteRSSExtensions(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(podcastString))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+
+	got, _ := roundTrip(t, feed, rss.FormatRSS)
+
+	if got.ITunes == nil || got.ITunes.Image.String() != feed.ITunes.Image.String() || got.ITunes.Type != feed.ITunes.Type {
+		t.Fatalf("expected iTunes metadata %+v, got %+v", feed.ITunes, got.ITunes)
+	}
+	if got.Podcast == nil || got.Podcast.GUID != feed.Podcast.GUID || got.Podcast.Locked != feed.Podcast.Locked || len(got.Podcast.Persons) != len(feed.Podcast.Persons) {
+		t.Fatalf("expected podcast metadata %+v, got %+v", feed.Podcast, got.Podcast)
+	}
+
+	// the second episode has transcripts, chapters and guests
+	item, orig := got.Items[1], feed.Items[1]
+	if item.ITunes.Duration != orig.ITunes.Duration || item.ITunes.Episode != orig.ITunes.Episode {
+		t.Fatalf("expected episode metadata %+v, got %+v", orig.ITunes, item.ITunes)
+	}
+	if item.Podcast == nil || len(item.Podcast.Transcripts) != 2 || item.Podcast.Transcripts[1].Rel != "captions" ||
+		item.Podcast.Chapters == nil || len(item.Podcast.Persons) != len(orig.Podcast.Persons) {
+		t.Fatalf("expected episode files %+v, got %+v", orig.Podcast, item.Podcast)
+	}
+}
+
+func TestWriteRSSChannel(t *testing.T) {
+	feed, err := rss.ParseRSS(strings.NewReader(rss2String))
+	if err != nil {
+		t.Fatalf("ParseRSS: %s", err)
+	}
+	feed.SkipHours = []int{0, 23}
+	feed.TTL = 60
+
+	got, written := roundTrip(t, feed, rss.FormatRSS)
+
+	if got.Image.URL.String() != feed.Image.URL.String() || got.Image.Width != feed.Image.Width || got.Image.Description != feed.Image.Description ||
+		got.TextInput.Name != feed.TextInput.Name || got.TextInput.Link.String() != feed.TextInput.Link.String() || got.Copyright != feed.Copyright || !got.LastBuildDate.Equal(*feed.LastBuildDate) {
+		t.Fatalf("channel metadata changed from %+v to %+v", feed, got)
+	}
+	if got.Cloud == nil || *got.Cloud != *feed.Cloud {
+		t.Fatalf("expected cloud %+v, got %+v", feed.Cloud, got.Cloud)
+	}
+	if len(got.SkipHours) != 2 || got.SkipHours[1] != 23 || got.TTL != 60 || len(got.Categories) != len(feed.Categories) {
+		t.Fatalf("unexpected skipHours %v, ttl %d and categories %v", got.SkipHours, got.TTL, got.Categories)
+	}
+	if !got.Items[0].GUIDIsPermaLink || strings.Contains(written, "xmlns:itunes") {
+		t.Fatalf("expected a permalink and no unused namespaces, got\n%s", written)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	var buf bytes.Buffer
+	if err := rss.Write(&buf, &rss.Feed{Title: "a"}, rss.FormatRDF); err == nil {
+		t.Fatal("expected an error for RDF")
+	}
+}
@@ -0,0 +1,339 @@
+// Package sanitize cleans the HTML of feeds so it is safe to render, and converts it to plain text
+package sanitize
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Policy is an allowlist of the elements and attributes kept by Sanitize. Everything not allowed is removed
+type Policy struct {
+	// The allowed elements by tag name, with the attributes allowed on each. The content of other elements is kept, without their tags
+	Elements map[string][]string
+	// The attributes allowed on every allowed element
+	GlobalAttributes []string
+	// The schemes allowed in URL attributes like href and src. Relative URLs are always allowed
+	URLSchemes []string
+	// Elements that are removed along with everything in them, instead of being replaced with their content
+	DropContent []string
+	// Whether images with a width or height of 1 pixel or less, which are used for tracking, are removed
+	DropTrackingPixels bool
+}
+
+// Attributes that hold a URL, which must have one of the URLSchemes of a Policy
+var urlAttributes = []string{"href", "src", "cite", "poster"}
+
+// Elements without an end tag
+var voidElements = []string{"area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "param", "source", "track", "wbr"}
+
+// Elements that start a new line in plain text
+var blockElements = []string{
+	"address", "article", "aside", "blockquote", "br", "dd", "div", "dl", "dt", "figcaption", "figure", "footer",
+	"h1", "h2", "h3", "h4", "h5", "h6", "header", "hr", "li", "ol", "p", "pre", "section", "table", "tr", "ul",
+}
+
+// Elements whose content is never text, see Text
+var hiddenElements = []string{"script", "style", "noscript", "template", "iframe", "object", "embed", "svg", "math", "head", "title"}
+
+// NewPolicy returns the default policy, which keeps text formatting, links, lists, tables, images and media,
+// and removes scripts, styles, frames, embedded objects, event handlers, inline styles and tracking pixels
+func NewPolicy() *Policy {
+	return &Policy{
+		Elements: map[string][]string{
+			"a":          {"href"},
+			"abbr":       nil,
+			"audio":      {"src", "controls"},
+			"b":          nil,
+			"blockquote": {"cite"},
+			"br":         nil,
+			"caption":    nil,
+			"code":       nil,
+			"dd":         nil,
+			"del":        nil,
+			"div":        nil,
+			"dl":         nil,
+			"dt":         nil,
+			"em":         nil,
+			"figcaption": nil,
+			"figure":     nil,
+			"h1":         nil,
+			"h2":         nil,
+			"h3":         nil,
+			"h4":         nil,
+			"h5":         nil,
+			"h6":         nil,
+			"hr":         nil,
+			"i":          nil,
+			"img":        {"src", "alt", "width", "height"},
+			"ins":        nil,
+			"li":         nil,
+			"ol":         {"start"},
+			"p":          nil,
+			"pre":        nil,
+			"q":          {"cite"},
+			"s":          nil,
+			"small":      nil,
+			"source":     {"src", "type"},
+			"span":       nil,
+			"strong":     nil,
+			"sub":        nil,
+			"sup":        nil,
+			"table":      nil,
+			"tbody":      nil,
+			"td":         {"colspan", "rowspan"},
+			"tfoot":      nil,
+			"th":         {"colspan", "rowspan"},
+			"thead":      nil,
+			"tr":         nil,
+			"u":          nil,
+			"ul":         nil,
+			"video":      {"src", "controls", "poster", "width", "height"},
+		},
+		GlobalAttributes:   []string{"title", "lang", "dir"},
+		URLSchemes:         []string{"http", "https", "mailto"},
+		DropContent:        slices.Clone(hiddenElements),
+		DropTrackingPixels: true,
+	}
+}
+
+// Sanitize returns the HTML fragment s with only the elements and attributes allowed by the policy. The result is well-formed:
+// text is escaped, and elements left open are closed
+func (p *Policy) Sanitize(s string) string {
+	var sb strings.Builder
+	var open []string
+	// the element being dropped with its content, and how deeply it is nested in itself
+	var dropping string
+	depth := 0
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				sb.WriteString("</" + open[i] + ">")
+			}
+			return sb.String()
+		case html.TextToken:
+			if depth == 0 {
+				sb.WriteString(html.EscapeString(string(z.Text())))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			void := tt == html.SelfClosingTagToken || slices.Contains(voidElements, t.Data)
+			if depth > 0 {
+				if t.Data == dropping && !void {
+					depth++
+				}
+				continue
+			}
+			if slices.Contains(p.DropContent, t.Data) {
+				if !void {
+					dropping, depth = t.Data, 1
+				}
+				continue
+			}
+
+			allowed, ok := p.Elements[t.Data]
+			if !ok || (p.DropTrackingPixels && t.Data == "img" && isTrackingPixel(t)) {
+				continue
+			}
+
+			sb.WriteString("<" + t.Data)
+			for _, a := range t.Attr {
+				if a.Namespace != "" || !(slices.Contains(allowed, a.Key) || slices.Contains(p.GlobalAttributes, a.Key)) {
+					continue
+				}
+				if slices.Contains(urlAttributes, a.Key) && !p.allowedURL(a.Val) {
+					continue
+				}
+				sb.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+			}
+			sb.WriteString(">")
+
+			if !slices.Contains(voidElements, t.Data) {
+				if tt == html.SelfClosingTagToken {
+					sb.WriteString("</" + t.Data + ">")
+				} else {
+					open = append(open, t.Data)
+				}
+			}
+		case html.EndTagToken:
+			t := z.Token()
+			if depth > 0 {
+				if t.Data == dropping {
+					depth--
+				}
+				continue
+			}
+
+			// close the elements left open inside this one, an end tag without a start tag is dropped
+			i := len(open) - 1
+			for i >= 0 && open[i] != t.Data {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			for len(open) > i {
+				sb.WriteString("</" + open[len(open)-1] + ">")
+				open = open[:len(open)-1]
+			}
+		}
+	}
+}
+
+// allowedURL reports whether raw is a relative URL or has one of the URLSchemes of the policy
+func (p *Policy) allowedURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || slices.Contains(p.URLSchemes, strings.ToLower(u.Scheme))
+}
+
+// isTrackingPixel reports whether the <img> t is 1 pixel wide or high
+func isTrackingPixel(t html.Token) bool {
+	for _, a := range t.Attr {
+		if a.Key != "width" && a.Key != "height" {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(a.Val), "px")); err == nil && n <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Text returns the text of the HTML fragment s without markup. Block elements like paragraphs are put on lines of their own,
+// and other whitespace is collapsed. Scripts, styles and the like are left out
+func Text(s string) string {
+	var sb strings.Builder
+	var hidden string
+	depth := 0
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for tt := z.Next(); tt != html.ErrorToken; tt = z.Next() {
+		name, _ := z.TagName()
+		tag := string(name)
+		switch tt {
+		case html.TextToken:
+			// line breaks in the source are whitespace like any other, lines only come from elements
+			if depth == 0 {
+				sb.WriteString(strings.ReplaceAll(string(z.Text()), "\n", " "))
+			}
+		case html.StartTagToken:
+			switch {
+			case depth > 0:
+				if tag == hidden {
+					depth++
+				}
+			case slices.Contains(hiddenElements, tag) && !slices.Contains(voidElements, tag):
+				hidden, depth = tag, 1
+			case slices.Contains(blockElements, tag):
+				sb.WriteString("\n")
+			}
+		case html.SelfClosingTagToken:
+			if depth == 0 && slices.Contains(blockElements, tag) {
+				sb.WriteString("\n")
+			}
+		case html.EndTagToken:
+			switch {
+			case depth > 0:
+				if tag == hidden {
+					depth--
+				}
+			case slices.Contains(blockElements, tag):
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ResolveURLs returns the HTML fragment s with the URLs in its attributes, like href, src and srcset, resolved against base.
+// Tags without relative URLs and everything between tags are returned exactly as they are in s
+func ResolveURLs(s string, base *url.URL) string {
+	if base == nil {
+		return s
+	}
+
+	var sb strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return sb.String()
+		}
+		// copied, as reading the token lowercases the tag name in place
+		raw := string(z.Raw())
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			sb.WriteString(raw)
+			continue
+		}
+
+		t := z.Token()
+		changed := false
+		for i, a := range t.Attr {
+			v := a.Val
+			switch {
+			case a.Namespace != "":
+			case a.Key == "srcset":
+				v = resolveSrcset(base, a.Val)
+			case slices.Contains(urlAttributes, a.Key):
+				v = resolve(base, a.Val)
+			}
+			if v != a.Val {
+				t.Attr[i].Val = v
+				changed = true
+			}
+		}
+
+		if changed {
+			sb.WriteString(t.String())
+		} else {
+			sb.WriteString(raw)
+		}
+	}
+}
+
+// resolve returns raw resolved against base, or raw itself if it is empty, absolute or malformed
+func resolve(base *url.URL, raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || raw == "" || u.IsAbs() {
+		return raw
+	}
+	return base.ResolveReference(u).String()
+}
+
+// resolveSrcset resolves the URL of each candidate in a srcset like "a.png 1x, b.png 2x"
+func resolveSrcset(base *url.URL, srcset string) string {
+	candidates := strings.Split(srcset, ",")
+	changed := false
+	for i, c := range candidates {
+		fields := strings.Fields(c)
+		if len(fields) == 0 {
+			continue
+		}
+		if u := resolve(base, fields[0]); u != fields[0] {
+			fields[0] = u
+			changed = true
+		}
+		candidates[i] = strings.Join(fields, " ")
+	}
+	if !changed {
+		return srcset
+	}
+	return strings.Join(candidates, ", ")
+}
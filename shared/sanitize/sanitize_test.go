@@ -0,0 +1,70 @@
+package sanitize_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/its-mrarsikk/fedup/shared/sanitize"
+)
+
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		name, in, expected string
+	}{
+		{"formatting", `<p>Hello <b>world</b></p>`, `<p>Hello <b>world</b></p>`},
+		{"script", `<p>a</p><script>alert("x")</script><p>b</p>`, `<p>a</p><p>b</p>`},
+		{"iframe", `<iframe src="https://evil.example/"><p>fallback</p></iframe>ok`, `ok`},
+		{"event handler", `<img src="https://example.com/a.png" onerror="alert(1)" alt="A">`, `<img src="https://example.com/a.png" alt="A">`},
+		{"javascript url", `<a href="javascript:alert(1)" title="t">link</a>`, `<a title="t">link</a>`},
+		{"obfuscated url", `<a href="java&#x09;script:alert(1)">link</a>`, `<a>link</a>`},
+		{"relative url", `<a href="/post/1" style="color: red">link</a>`, `<a href="/post/1">link</a>`},
+		{"tracking pixel", `text<img src="https://t.example/p.gif" width="1" height="1">`, `text`},
+		{"unknown element", `<blink>still <i>here</i></blink>`, `still <i>here</i>`},
+		{"unclosed", `<ul><li>one<li>two`, `<ul><li>one<li>two</li></li></ul>`},
+		{"stray end tag", `a</div></p>b`, `ab`},
+		{"escaping", `1 &lt; 2 &amp; "3"`, `1 &lt; 2 &amp; &#34;3&#34;`},
+		{"comment", `a<!-- <script>x</script> -->b`, `ab`},
+		{"nested drop", `<object><object>x</object>y</object>z`, `z`},
+	}
+
+	p := sanitize.NewPolicy()
+	for _, c := range cases {
+		if got := p.Sanitize(c.in); got != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	p := sanitize.NewPolicy()
+	p.Elements["iframe"] = []string{"src"}
+	p.DropContent = nil
+	p.URLSchemes = []string{"https"}
+	p.DropTrackingPixels = false
+
+	const in = `<iframe src="https://video.example/1"></iframe><a href="http://example.com/">x</a><img src="https://t.example/p.gif" width="1">`
+	const expected = `<iframe src="https://video.example/1"></iframe><a>x</a><img src="https://t.example/p.gif" width="1">`
+	if got := p.Sanitize(in); got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestText(t *testing.T) {
+	const in = `<h1>Title</h1><p>First   paragraph,
+	with a <a href="/x">link</a>.</p><style>p { color: red }</style><ul><li>one</li><li>two &amp; three</li></ul>end<br>line`
+	const expected = "Title\nFirst paragraph, with a link.\none\ntwo & three\nend\nline"
+
+	if got := sanitize.Text(in); got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestResolveURLs(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post/")
+	const in = `<P Class="x"><A HREF="../other">rel</A> <a href="https://example.org/">abs</a> <img src="/a.png" srcset="b.png 2x"><!-- <a href="c"> --></P>`
+	const expected = `<P Class="x"><a href="https://example.com/blog/other">rel</A> <a href="https://example.org/">abs</a> <img src="https://example.com/a.png" srcset="https://example.com/blog/post/b.png 2x"><!-- <a href="c"> --></P>`
+
+	if got := sanitize.ResolveURLs(in, base); got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}